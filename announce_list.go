@@ -0,0 +1,89 @@
+package ben
+
+import (
+	"math/rand/v2"
+	"slices"
+)
+
+// AnnounceList holds tiers of tracker URLs as described in BEP 12.
+type AnnounceList [][]string
+
+// Trackers returns the tracker tiers a client should use for this torrent.
+// As in BEP 12, `announce` is ignored when announce-list holds any tracker
+// and only used as a fallback otherwise. Duplicated URLs and empty tiers
+// are removed.
+func (t Torrent) Trackers() AnnounceList {
+	var (
+		tiers AnnounceList
+		seen  = make(map[string]struct{})
+	)
+
+	for _, tier := range t.AnnounceList {
+		var deduped []string
+
+		for _, url := range tier {
+			if _, ok := seen[url]; ok || url == "" {
+				continue
+			}
+
+			seen[url] = struct{}{}
+			deduped = append(deduped, url)
+		}
+
+		if len(deduped) > 0 {
+			tiers = append(tiers, deduped)
+		}
+	}
+
+	if len(tiers) == 0 && t.Announce != "" {
+		tiers = AnnounceList{{t.Announce}}
+	}
+
+	return tiers
+}
+
+// Shuffle randomizes the order of trackers within each tier,
+// the order of the tiers themselves is kept.
+// If r is nil, the global random source is used.
+func (al AnnounceList) Shuffle(r *rand.Rand) {
+	shuffle := rand.Shuffle
+	if r != nil {
+		shuffle = r.Shuffle
+	}
+
+	for _, tier := range al {
+		shuffle(len(tier), func(i, j int) {
+			tier[i], tier[j] = tier[j], tier[i]
+		})
+	}
+}
+
+// Promote moves url to the front of its tier, this should be called
+// after a successful announce to that tracker.
+// It returns false if url is not in the list.
+func (al AnnounceList) Promote(url string) bool {
+	for _, tier := range al {
+		idx := slices.Index(tier, url)
+		if idx < 0 {
+			continue
+		}
+
+		copy(tier[1:idx+1], tier[:idx])
+		tier[0] = url
+
+		return true
+	}
+
+	return false
+}
+
+// Flatten returns all tracker URLs in the order they should be tried.
+func (al AnnounceList) Flatten() []string {
+	var urls []string
+
+	for _, tier := range al {
+		urls = append(urls, tier...)
+	}
+
+	return urls
+}
@@ -0,0 +1,103 @@
+package ben_test
+
+import (
+	"bufio"
+	"bytes"
+	"math/rand/v2"
+
+	"github.com/fudanchii/ben"
+	"github.com/fudanchii/infr"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Announce List", func() {
+	//nolint: lll
+	const source = "d8:announce17:http://a/announce13:announce-listll17:http://a/announce17:http://b/announceel17:http://c/announce17:http://a/announceee4:infod4:name3:abc6:pieces0:ee"
+
+	var (
+		torrent ben.Torrent
+		err     error
+	)
+
+	BeforeEach(func() {
+		var dict ben.Dictionary
+
+		dict, err = ben.Decode[ben.Dictionary](bufio.NewReader(bytes.NewBufferString(source)))
+		Expect(err).NotTo(HaveOccurred())
+
+		torrent, err = infr.TryFrom[ben.Dictionary, ben.Torrent](dict).TryInto()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("decodes every tier", func() {
+		Expect(torrent.AnnounceList).To(Equal(ben.AnnounceList{
+			{"http://a/announce", "http://b/announce"},
+			{"http://c/announce", "http://a/announce"},
+		}))
+	})
+
+	It("survives re-encoding", func() {
+		dict, encErr := torrent.TryInto()
+		Expect(encErr).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(Equal(source))
+	})
+
+	It("keeps entries it does not model", func() {
+		source := "d7:comment2:hi4:infod4:name3:abc6:pieces0:ee"
+
+		dict, decErr := ben.Decode[ben.Dictionary](bufio.NewReader(bytes.NewBufferString(source)))
+		Expect(decErr).NotTo(HaveOccurred())
+
+		withComment, decErr := ben.Torrent{}.TryFrom(dict)
+		Expect(decErr).NotTo(HaveOccurred())
+		Expect(withComment.Extra).To(Equal(map[string]ben.Element{"comment": ben.Str("hi")}))
+
+		dict, encErr := withComment.TryInto()
+		Expect(encErr).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(Equal(source))
+	})
+
+	It("dedupes trackers across announce and announce-list", func() {
+		Expect(torrent.Trackers()).To(Equal(ben.AnnounceList{
+			{"http://a/announce", "http://b/announce"},
+			{"http://c/announce"},
+		}))
+	})
+
+	It("ignores announce when announce-list is set", func() {
+		torrent.Announce = "http://d/announce"
+		Expect(torrent.Trackers()).To(Equal(ben.AnnounceList{
+			{"http://a/announce", "http://b/announce"},
+			{"http://c/announce"},
+		}))
+	})
+
+	It("falls back to announce when there is no announce-list", func() {
+		torrent.AnnounceList = nil
+		Expect(torrent.Trackers()).To(Equal(ben.AnnounceList{{"http://a/announce"}}))
+
+		torrent.AnnounceList = ben.AnnounceList{{}, {""}}
+		Expect(torrent.Trackers()).To(Equal(ben.AnnounceList{{"http://a/announce"}}))
+	})
+})
+
+var _ = Describe("Announce List tiers", func() {
+	It("promotes a responding tracker within its tier", func() {
+		tiers := ben.AnnounceList{{"a", "b", "c"}, {"d"}}
+		Expect(tiers.Promote("c")).To(BeTrue())
+		Expect(tiers).To(Equal(ben.AnnounceList{{"c", "a", "b"}, {"d"}}))
+		Expect(tiers.Promote("x")).To(BeFalse())
+	})
+
+	It("shuffles only within a tier", func() {
+		tiers := ben.AnnounceList{{"a", "b", "c", "d"}, {"e"}}
+		tiers.Shuffle(rand.New(rand.NewPCG(1, 2)))
+		Expect(tiers[0]).To(ConsistOf("a", "b", "c", "d"))
+		Expect(tiers[1]).To(Equal([]string{"e"}))
+	})
+})
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
 	"strconv"
 )
 
@@ -102,6 +104,34 @@ func Decode[B Bencoder[B]](input ReadPeeker) (B, error) {
 	return b.Decode(input)
 }
 
+// DecodePrefix decodes the element at the start of b and returns the number of bytes it spans,
+// data trailing the element, such as the metadata piece of a ut_metadata message, starts there.
+func DecodePrefix[B Bencoder[B]](b []byte) (B, int, error) {
+	input := &prefixReader{Reader: bytes.NewReader(b)}
+
+	elm, err := Decode[B](input)
+	if err != nil {
+		return elm, 0, err
+	}
+
+	return elm, len(b) - input.Len(), nil
+}
+
+// prefixReader is a ReadPeeker over a byte slice that does not read ahead,
+// so the unread length tells where a decoded element ended.
+type prefixReader struct {
+	*bytes.Reader
+}
+
+func (r *prefixReader) Peek(n int) ([]byte, error) {
+	offset := r.Size() - int64(r.Len())
+	peeked := make([]byte, n)
+
+	read, err := r.ReadAt(peeked, offset)
+
+	return peeked[:read], err
+}
+
 type Integer struct {
 	V[int64]
 }
@@ -416,9 +446,10 @@ func (d Dictionary) Type() ElementType {
 func (d Dictionary) Encode() []byte {
 	var buff bytes.Buffer
 	buff.WriteByte(StartDict)
-	for k, v := range d.Val {
+	// keys must appear in sorted order for the output to be canonical bencode
+	for _, k := range slices.Sorted(maps.Keys(d.Val)) {
 		buff.Write(Str(k).Encode())
-		buff.Write(v.Encode())
+		buff.Write(d.Val[k].Encode())
 	}
 	buff.WriteByte(EndItemSeq)
 	return buff.Bytes()
@@ -4,6 +4,8 @@ package ben_test
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha1"
+	"fmt"
 	"os"
 	"testing"
 	"time"
@@ -366,6 +368,12 @@ var _ = Describe("Bencode", func() {
 					Expect(torrent.Info.Pieces[0]).
 						To(Equal(ben.SHA1("\x9a\xe7\x47\x53\x58\x35\x0c\x00\x25\x86\xfe\x2c\x48\x4c\x6c\x62\x66\x10\xb2\x9d")))
 				})
+
+				It("should encode back into the same dictionary", func() {
+					dict, encErr := torrent.TryInto()
+					Expect(encErr).NotTo(HaveOccurred())
+					Expect(dict.Encode()).To(Equal(torrentDict.Encode()))
+				})
 			})
 		})
 	})
@@ -439,6 +447,20 @@ var _ = Describe("Bencode", func() {
 						)))
 				})
 
+				It("should have the correct 'info.private' value", func() {
+					Expect(torrent.Info.Private).To(BeTrue())
+				})
+
+				It("should keep the info-hash when re-encoded", func() {
+					infoDict, encErr := torrent.Info.TryInto()
+					Expect(encErr).NotTo(HaveOccurred())
+					Expect(infoDict.Encode()).To(Equal(torrentDict.Val["info"].Encode()))
+
+					hash, hashErr := torrent.Info.Hash()
+					Expect(hashErr).NotTo(HaveOccurred())
+					Expect(hash.String()).To(Equal(fmt.Sprintf("%x", sha1.Sum(torrentDict.Val["info"].Encode()))))
+				})
+
 				It("should have the correct 'info.files' value", func() {
 					Expect(torrent.Info.Files).To(HaveLen(3))
 
@@ -469,3 +491,21 @@ var _ = Describe("Bencode", func() {
 		})
 	})
 })
+
+var _ = Describe("Info dictionary", func() {
+	It("keeps 'private' and 'source' through a decode/encode round trip", func() {
+		source := "d4:name3:abc6:pieces0:7:privatei1e6:source3:TRKe"
+
+		infoDict, err := ben.Decode[ben.Dictionary](bufio.NewReader(bytes.NewBufferString(source)))
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := infr.TryFrom[ben.Dictionary, ben.Info](infoDict).TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Private).To(BeTrue())
+		Expect(info.Source).To(Equal("TRK"))
+
+		encoded, err := info.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(encoded.Encode())).To(Equal(source))
+	})
+})
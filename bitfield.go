@@ -0,0 +1,36 @@
+package ben
+
+import "math/bits"
+
+// Bitfield is a set of pieces packed as in the peer wire `bitfield` message,
+// the high bit of the first byte is piece 0.
+type Bitfield []byte
+
+// NewBitfield returns an empty bitfield of n pieces.
+func NewBitfield(n int) Bitfield {
+	return make(Bitfield, (n+7)/8)
+}
+
+// Has reports whether piece is set, pieces out of range are not.
+func (b Bitfield) Has(piece int) bool {
+	if piece < 0 || piece/8 >= len(b) {
+		return false
+	}
+
+	return b[piece/8]&(0x80>>(piece%8)) != 0
+}
+
+// Set marks piece, which must be in range.
+func (b Bitfield) Set(piece int) {
+	b[piece/8] |= 0x80 >> (piece % 8)
+}
+
+// Count returns the number of pieces set.
+func (b Bitfield) Count() int {
+	var n int
+	for _, v := range b {
+		n += bits.OnesCount8(v)
+	}
+
+	return n
+}
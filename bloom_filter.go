@@ -0,0 +1,65 @@
+package ben
+
+import (
+	"crypto/sha1" //nolint: gosec // mandated by BEP 33
+	"math"
+	"math/bits"
+	"net/netip"
+)
+
+const (
+	bloomFilterBits   = 2048
+	bloomFilterHashes = 2
+)
+
+// BloomFilter is the 256 bytes bloom filter of the IPs of a swarm, as sent in scrape
+// responses (BEP 33).
+type BloomFilter [bloomFilterBits / 8]byte
+
+func (f *BloomFilter) indexes(addr netip.Addr) [bloomFilterHashes]int {
+	sum := sha1.Sum(addr.Unmap().AsSlice()) //nolint: gosec // see import
+
+	return [bloomFilterHashes]int{
+		(int(sum[0]) | int(sum[1])<<8) % bloomFilterBits,
+		(int(sum[2]) | int(sum[3])<<8) % bloomFilterBits,
+	}
+}
+
+// Insert adds addr to the filter.
+func (f *BloomFilter) Insert(addr netip.Addr) {
+	for _, idx := range f.indexes(addr) {
+		f[idx/8] |= 1 << (idx % 8)
+	}
+}
+
+// Contains reports whether addr may have been inserted into the filter.
+func (f *BloomFilter) Contains(addr netip.Addr) bool {
+	for _, idx := range f.indexes(addr) {
+		if f[idx/8]&(1<<(idx%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Union adds the IPs of other to the filter.
+func (f *BloomFilter) Union(other *BloomFilter) {
+	for i := range f {
+		f[i] |= other[i]
+	}
+}
+
+// Estimate returns the approximate number of distinct IPs inserted into the filter.
+func (f *BloomFilter) Estimate() float64 {
+	var set int
+	for _, b := range f {
+		set += bits.OnesCount8(b)
+	}
+
+	// a full filter is counted as having a single zero bit to avoid ln(0)
+	unset := max(bloomFilterBits-set, 1)
+
+	return math.Log(float64(unset)/bloomFilterBits) /
+		(bloomFilterHashes * math.Log(1-1.0/bloomFilterBits))
+}
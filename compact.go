@@ -0,0 +1,74 @@
+package ben
+
+import (
+	"encoding/binary"
+	"net/netip"
+)
+
+const (
+	compactIPv4Len = 6
+	compactIPv6Len = 18
+)
+
+// ParseCompactAddrPort parses a 6-byte IPv4 or an 18-byte IPv6 compact address,
+// the port is in network byte order.
+func ParseCompactAddrPort(b []byte) (netip.AddrPort, error) {
+	if len(b) != compactIPv4Len && len(b) != compactIPv6Len {
+		return netip.AddrPort{}, ErrInvalidCompactAddr
+	}
+
+	addr, _ := netip.AddrFromSlice(b[:len(b)-2])
+	port := binary.BigEndian.Uint16(b[len(b)-2:])
+
+	return netip.AddrPortFrom(addr, port), nil
+}
+
+// AppendCompactAddrPort appends the compact form of addrPort to b,
+// IPv4-mapped IPv6 addresses are written as IPv4.
+func AppendCompactAddrPort(b []byte, addrPort netip.AddrPort) []byte {
+	addr := addrPort.Addr().Unmap()
+	b = append(b, addr.AsSlice()...)
+
+	return binary.BigEndian.AppendUint16(b, addrPort.Port())
+}
+
+// ParseCompactPeers parses a string of concatenated compact addresses, each size bytes long.
+// size is either 6 for IPv4 peers or 18 for IPv6 peers.
+func ParseCompactPeers(b []byte, size int) ([]netip.AddrPort, error) {
+	if size != compactIPv4Len && size != compactIPv6Len || len(b)%size != 0 {
+		return nil, ErrInvalidCompactAddr
+	}
+
+	peers := make([]netip.AddrPort, 0, len(b)/size)
+	for start := 0; start < len(b); start += size {
+		peer, err := ParseCompactAddrPort(b[start : start+size])
+		if err != nil {
+			return nil, err
+		}
+
+		peers = append(peers, peer)
+	}
+
+	return peers, nil
+}
+
+// CompactPeers concatenates the compact form of every peer, each size bytes long.
+// Peers that do not fit, such as IPv6 addresses in 6-byte entries or IPv4-mapped
+// addresses in 18-byte entries, are rejected.
+func CompactPeers(peers []netip.AddrPort, size int) ([]byte, error) {
+	b := make([]byte, 0, len(peers)*size)
+	for _, peer := range peers {
+		addr := peer.Addr()
+
+		switch {
+		case size == compactIPv4Len && addr.Unmap().Is4():
+		case size == compactIPv6Len && addr.Is6() && !addr.Is4In6():
+		default:
+			return nil, ErrInvalidCompactAddr
+		}
+
+		b = AppendCompactAddrPort(b, peer)
+	}
+
+	return b, nil
+}
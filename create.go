@@ -0,0 +1,298 @@
+package ben
+
+import (
+	"crypto/sha1" //nolint: gosec // sha1 is mandated by the spec
+	"hash"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxPieceCount  = 1500
+	maxPieceLength = 16 * 1024 * 1024
+)
+
+// CreateOptions configures CreateTorrent.
+type CreateOptions struct {
+	// Name defaults to the base name of the root path.
+	Name string
+	// PieceLength is picked from the content size when zero.
+	PieceLength int64
+	// Hybrid adds v1 metadata, with padding files so v1 pieces line up with v2 files.
+	Hybrid bool
+
+	Announce     string
+	AnnounceList AnnounceList
+	URLList      URLList
+	Private      bool
+	Source       string
+	CreatedBy    string
+	CreationDate time.Time
+}
+
+type sourceFile struct {
+	fsPath string
+	path   []string
+	length int64
+}
+
+// CreateTorrent creates a v2-only or a hybrid v1+v2 torrent from root within fsys.
+// A root pointing to a regular file creates a single-file torrent.
+func CreateTorrent(fsys fs.FS, root string, opts CreateOptions) (Torrent, error) {
+	var torrent Torrent
+
+	files, singleFile, err := collectFiles(fsys, root)
+	if err != nil {
+		return torrent, err
+	}
+
+	name := opts.Name
+	if name == "" && root != "." {
+		name = path.Base(root)
+	}
+
+	if name == "" {
+		return torrent, ErrEmptyTorrentName
+	}
+
+	pieceLength := opts.PieceLength
+	if pieceLength == 0 {
+		pieceLength = defaultPieceLength(files)
+	}
+
+	if pieceLength < BlockSize || pieceLength&(pieceLength-1) != 0 {
+		return torrent, ErrInvalidPieceLength
+	}
+
+	info := Info{
+		Name:        name,
+		PieceLength: pieceLength,
+		MetaVersion: 2, //nolint: mnd // meta version from BEP 52
+		Private:     opts.Private,
+		Source:      opts.Source,
+	}
+
+	var v1 *pieceHasher
+	if opts.Hybrid {
+		v1 = newPieceHasher(pieceLength)
+	}
+
+	layers := make(PieceLayers)
+	tree := FileTree{Entries: make(map[string]FileTree)}
+
+	for idx, file := range files {
+		treeFile, layer, err := hashSourceFile(fsys, file, pieceLength, v1)
+		if err != nil {
+			return torrent, err
+		}
+
+		treePath := file.path
+		if singleFile {
+			treePath = []string{name}
+		}
+
+		tree.insert(treePath, treeFile)
+
+		if layer != nil {
+			layers[treeFile.PiecesRoot] = layer
+		}
+
+		if v1 == nil || singleFile {
+			continue
+		}
+
+		info.Files = append(info.Files, File{Length: file.length, Path: file.path})
+
+		// align the next file to a piece boundary
+		if padding := (pieceLength - file.length%pieceLength) % pieceLength; padding > 0 && idx < len(files)-1 {
+			_, _ = v1.Write(make([]byte, padding))
+			info.Files = append(info.Files, File{
+				Length: padding,
+				Path:   []string{".pad", strconv.FormatInt(padding, 10)},
+				Attr:   string(AttrPadding),
+			})
+		}
+	}
+
+	info.FileTree = tree
+
+	if v1 != nil {
+		info.Pieces = v1.Sum()
+		if singleFile {
+			info.Length = files[0].length
+		}
+	}
+
+	torrent = Torrent{
+		Announce:     opts.Announce,
+		AnnounceList: opts.AnnounceList,
+		URLList:      opts.URLList,
+		Info:         info,
+	}
+
+	if len(layers) > 0 {
+		torrent.PieceLayers = layers
+	}
+
+	if opts.CreatedBy != "" {
+		torrent.CreatedBy = &opts.CreatedBy
+	}
+
+	if !opts.CreationDate.IsZero() {
+		torrent.CreationDate = &opts.CreationDate
+	}
+
+	return torrent, nil
+}
+
+func collectFiles(fsys fs.FS, root string) ([]sourceFile, bool, error) {
+	var files []sourceFile
+
+	stat, err := fs.Stat(fsys, root)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if stat.Mode().IsRegular() {
+		return []sourceFile{{fsPath: root, length: stat.Size()}}, true, nil
+	}
+
+	// WalkDir visits entries in lexical order, which is also the order of the file tree.
+	err = fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(p, root+"/")
+		if root == "." {
+			rel = p
+		}
+
+		files = append(files, sourceFile{fsPath: p, path: strings.Split(rel, "/"), length: fi.Size()})
+
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(files) == 0 {
+		return nil, false, ErrNoFiles
+	}
+
+	return files, false, nil
+}
+
+func defaultPieceLength(files []sourceFile) int64 {
+	var total int64
+	for _, file := range files {
+		total += file.length
+	}
+
+	pieceLength := int64(BlockSize)
+	for pieceLength < maxPieceLength && total/pieceLength > maxPieceCount {
+		pieceLength *= 2
+	}
+
+	return pieceLength
+}
+
+func hashSourceFile(fsys fs.FS, file sourceFile, pieceLength int64, v1 *pieceHasher) (FileTreeFile, []SHA256, error) {
+	treeFile := FileTreeFile{Length: file.length}
+
+	f, err := fsys.Open(file.fsPath)
+	if err != nil {
+		return treeFile, nil, err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	if v1 != nil {
+		r = io.TeeReader(f, v1)
+	}
+
+	tree, length, err := MerkleTreeFromReader(r)
+	if err != nil {
+		return treeFile, nil, err
+	}
+
+	treeFile.Length = length
+	if length == 0 {
+		return treeFile, nil, nil
+	}
+
+	treeFile.PiecesRoot = tree.Root()
+
+	return treeFile, tree.PieceLayer(pieceLength, length), nil
+}
+
+func (t FileTree) insert(path []string, file FileTreeFile) {
+	// directory nodes share their Entries map, so updating a copy is enough
+	node := t
+	for _, name := range path[:len(path)-1] {
+		child, ok := node.Entries[name]
+		if !ok {
+			child = FileTree{Entries: make(map[string]FileTree)}
+			node.Entries[name] = child
+		}
+
+		node = child
+	}
+
+	node.Entries[path[len(path)-1]] = FileTree{File: &file}
+}
+
+// pieceHasher collects SHA-1 hashes of v1 pieces from a continuous stream.
+type pieceHasher struct {
+	pieceLength int64
+	filled      int64
+	current     hash.Hash
+	pieces      []SHA1
+}
+
+func newPieceHasher(pieceLength int64) *pieceHasher {
+	return &pieceHasher{pieceLength: pieceLength, current: sha1.New()} //nolint: gosec // see above
+}
+
+func (h *pieceHasher) Write(p []byte) (int, error) {
+	written := len(p)
+
+	for len(p) > 0 {
+		n := min(int64(len(p)), h.pieceLength-h.filled)
+		h.current.Write(p[:n])
+		h.filled += n
+		p = p[n:]
+
+		if h.filled == h.pieceLength {
+			h.pieces = append(h.pieces, h.current.Sum(nil))
+			h.current.Reset()
+			h.filled = 0
+		}
+	}
+
+	return written, nil
+}
+
+// Sum returns all piece hashes, including the last partial piece.
+func (h *pieceHasher) Sum() []SHA1 {
+	pieces := h.pieces
+	if h.filled > 0 {
+		pieces = append(pieces, h.current.Sum(nil))
+	}
+
+	if pieces == nil {
+		pieces = []SHA1{}
+	}
+
+	return pieces
+}
@@ -0,0 +1,128 @@
+package ben_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing/fstest"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+func hashPair(left, right ben.SHA256) ben.SHA256 {
+	return sha256.Sum256(append(left[:], right[:]...))
+}
+
+var _ = Describe("Torrent creation", func() {
+	Context("v2-only single file", func() {
+		var (
+			data    []byte
+			torrent ben.Torrent
+			err     error
+		)
+
+		BeforeEach(func() {
+			data = bytes.Repeat([]byte("x"), 2*ben.BlockSize+1)
+			fsys := fstest.MapFS{"big.bin": {Data: data}}
+
+			torrent, err = ben.CreateTorrent(fsys, "big.bin", ben.CreateOptions{PieceLength: 2 * ben.BlockSize})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("builds the merkle root and the piece layer", func() {
+			leaves := []ben.SHA256{
+				sha256.Sum256(data[:ben.BlockSize]),
+				sha256.Sum256(data[ben.BlockSize : 2*ben.BlockSize]),
+				sha256.Sum256(data[2*ben.BlockSize:]),
+			}
+			layer := []ben.SHA256{hashPair(leaves[0], leaves[1]), hashPair(leaves[2], ben.SHA256{})}
+
+			files := torrent.Info.FileTree.Files()
+			Expect(files).To(HaveLen(1))
+			Expect(files[0].Path).To(Equal([]string{"big.bin"}))
+			Expect(files[0].Length).To(Equal(int64(len(data))))
+			Expect(files[0].PiecesRoot).To(Equal(hashPair(layer[0], layer[1])))
+			Expect(torrent.PieceLayers).To(Equal(ben.PieceLayers{files[0].PiecesRoot: layer}))
+		})
+
+		It("has no v1 metadata", func() {
+			Expect(torrent.Info.IsV2()).To(BeTrue())
+			Expect(torrent.Info.IsHybrid()).To(BeFalse())
+			Expect(torrent.Info.Pieces).To(BeNil())
+		})
+	})
+
+	Context("hybrid multi-file", func() {
+		var (
+			fsys    fstest.MapFS
+			torrent ben.Torrent
+			err     error
+		)
+
+		BeforeEach(func() {
+			fsys = fstest.MapFS{
+				"abc/a.txt":     {Data: []byte("aaaaaa")},
+				"abc/dir/b.bin": {Data: bytes.Repeat([]byte("b"), 40000)},
+				"abc/z.txt":     {Data: []byte("zzzzzzzzzz")},
+			}
+
+			torrent, err = ben.CreateTorrent(fsys, "abc", ben.CreateOptions{
+				PieceLength: ben.BlockSize,
+				Hybrid:      true,
+				Announce:    "http://a/announce",
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("pads v1 files to piece boundaries", func() {
+			Expect(torrent.Info.IsHybrid()).To(BeTrue())
+			Expect(torrent.Info.Files).To(Equal([]ben.File{
+				{Length: 6, Path: []string{"a.txt"}},
+				{Length: 16378, Path: []string{".pad", "16378"}, Attr: "p"},
+				{Length: 40000, Path: []string{"dir", "b.bin"}},
+				{Length: 9152, Path: []string{".pad", "9152"}, Attr: "p"},
+				{Length: 10, Path: []string{"z.txt"}},
+			}))
+			Expect(torrent.Info.PieceCount()).To(Equal(5))
+		})
+
+		It("produces v1 pieces matching the files", func() {
+			for idx := range torrent.Info.PieceCount() {
+				piece, readErr := torrent.Info.ReadPiece(fsys, idx)
+				Expect(readErr).NotTo(HaveOccurred())
+
+				ok, verifyErr := torrent.Info.VerifyPiece(idx, piece)
+				Expect(verifyErr).NotTo(HaveOccurred())
+				Expect(ok).To(BeTrue())
+			}
+		})
+
+		It("lists the same files in the v2 file tree", func() {
+			files := torrent.Info.FileTree.Files()
+			Expect(files).To(HaveLen(3))
+			Expect(files[1].Path).To(Equal([]string{"dir", "b.bin"}))
+			Expect(torrent.PieceLayers).To(HaveKey(files[1].PiecesRoot))
+			Expect(torrent.PieceLayers[files[1].PiecesRoot]).To(HaveLen(3))
+		})
+
+		It("survives a decode/encode round trip", func() {
+			dict, encErr := torrent.TryInto()
+			Expect(encErr).NotTo(HaveOccurred())
+
+			decoded, decErr := decodeTorrent(string(dict.Encode()))
+			Expect(decErr).NotTo(HaveOccurred())
+			Expect(decoded).To(Equal(torrent))
+		})
+	})
+
+	It("rejects piece lengths that are not a power of two", func() {
+		fsys := fstest.MapFS{"a.txt": {Data: []byte("a")}}
+		_, err := ben.CreateTorrent(fsys, "a.txt", ben.CreateOptions{PieceLength: 3 * ben.BlockSize})
+		Expect(err).To(MatchError(ben.ErrInvalidPieceLength))
+	})
+})
@@ -0,0 +1,808 @@
+package ben
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1" //nolint: gosec // not used for security, only to derive tokens
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// lookupAlpha is the number of concurrent queries of an iterative lookup.
+	lookupAlpha = 3
+
+	defaultDHTTimeout  = 2 * time.Second
+	dhtMaxPacketSize   = 1500
+	dhtMaxPeersPerHash = 100
+
+	// secrets rotate every 5 minutes and tokens are accepted up to 10 minutes (BEP 5).
+	tokenRotation = 5 * time.Minute
+	dhtPeerTTL    = 30 * time.Minute
+
+	// bounds of the announced peers kept by a node, further announces are dropped.
+	dhtMaxStoredSwarms = 10000
+	dhtMaxStoredPeers  = 1000
+
+	// maxBackgroundPings bounds the pings sent to check nodes met by the way.
+	maxBackgroundPings = 16
+)
+
+// DHT is a Mainline DHT node (BEP 5) running over a packet conn, create it with NewDHT
+// and run Serve before sending any query. It is safe for concurrent use.
+type DHT struct {
+	ID NodeID
+	// Timeout of a single query, defaults to 2 seconds.
+	Timeout time.Duration
+
+	conn   net.PacketConn
+	table  *RoutingTable
+	tokens tokenSecrets
+	peers  peerStore
+	items  *ItemStore
+
+	mu       sync.Mutex
+	pending  map[string]pendingQuery
+	nextTID  uint16
+	closed   bool
+	external netip.AddrPort
+
+	pings chan struct{}
+}
+
+type pendingQuery struct {
+	addr   netip.AddrPort
+	respCh chan KRPCMessage
+}
+
+func NewDHT(conn net.PacketConn, id NodeID) *DHT {
+	return &DHT{
+		ID:      id,
+		conn:    conn,
+		table:   NewRoutingTable(id),
+		peers:   peerStore{swarms: make(map[InfoHash]map[netip.AddrPort]storedPeer), now: time.Now},
+		items:   NewItemStore(),
+		pending: make(map[string]pendingQuery),
+		pings:   make(chan struct{}, maxBackgroundPings),
+	}
+}
+
+// ExternalAddr returns the address of the node as last reported by a responding node (BEP 42),
+// ok is false until a node reported it.
+func (d *DHT) ExternalAddr() (netip.AddrPort, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.external, d.external.IsValid()
+}
+
+// RoutingTable returns the routing table of the node.
+func (d *DHT) RoutingTable() *RoutingTable {
+	return d.table
+}
+
+// Serve answers queries and dispatches responses until conn is closed.
+func (d *DHT) Serve() error {
+	defer d.close()
+
+	buff := make([]byte, dhtMaxPacketSize)
+
+	for {
+		n, addr, err := d.conn.ReadFrom(buff)
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		udpAddr, ok := addr.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+
+		from := udpAddr.AddrPort()
+		from = netip.AddrPortFrom(from.Addr().Unmap(), from.Port())
+
+		msg, err := ParseKRPCMessage(buff[:n])
+		if err != nil {
+			continue
+		}
+
+		if msg.Type == KRPCTypeQuery {
+			d.handleQuery(msg, from)
+			continue
+		}
+
+		// responses are only accepted from the queried address
+		d.mu.Lock()
+		pending, ok := d.pending[msg.TransactionID]
+		if ok && pending.addr == from {
+			delete(d.pending, msg.TransactionID)
+			pending.respCh <- msg
+		}
+		d.mu.Unlock()
+	}
+}
+
+func (d *DHT) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.closed = true
+	for tid, pending := range d.pending {
+		close(pending.respCh)
+		delete(d.pending, tid)
+	}
+}
+
+func (d *DHT) timeout() time.Duration {
+	if d.Timeout > 0 {
+		return d.Timeout
+	}
+
+	return defaultDHTTimeout
+}
+
+// query sends msg to addr and waits for its response, the routing table is updated
+// with the outcome.
+func (d *DHT) query(ctx context.Context, addr netip.AddrPort, msg KRPCMessage) (KRPCMessage, error) {
+	respCh := make(chan KRPCMessage, 1)
+
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return KRPCMessage{}, ErrDHTClosed
+	}
+
+	d.nextTID++
+	msg.TransactionID = string(binary.BigEndian.AppendUint16(nil, d.nextTID))
+	d.pending[msg.TransactionID] = pendingQuery{addr: addr, respCh: respCh}
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		delete(d.pending, msg.TransactionID)
+		d.mu.Unlock()
+	}()
+
+	if err := d.send(addr, msg); err != nil {
+		return KRPCMessage{}, err
+	}
+
+	timer := time.NewTimer(d.timeout())
+	defer timer.Stop()
+
+	select {
+	case resp, ok := <-respCh:
+		if !ok {
+			return KRPCMessage{}, ErrDHTClosed
+		}
+
+		if resp.Type == KRPCTypeError {
+			return resp, resp.Err()
+		}
+
+		d.responded(NodeInfo{ID: resp.Response.ID, Addr: addr})
+
+		if resp.IP.IsValid() {
+			d.mu.Lock()
+			d.external = resp.IP
+			d.mu.Unlock()
+		}
+
+		return resp, nil
+
+	case <-timer.C:
+		d.table.Failed(addr)
+		return KRPCMessage{}, ErrDHTTimeout
+
+	case <-ctx.Done():
+		return KRPCMessage{}, ctx.Err()
+	}
+}
+
+// responded adds node to the routing table, pinging a questionable node
+// of a full bucket to decide which one to keep.
+func (d *DHT) responded(node NodeInfo) {
+	stale, ok := d.table.Responded(node)
+	if !ok {
+		return
+	}
+
+	// every unanswered ping marks the stale node as failed, until it turns bad and gets replaced
+	d.goPing(func() {
+		for range nodeMaxFailures {
+			if _, err := d.Ping(context.Background(), stale.Addr); err == nil {
+				return
+			}
+		}
+
+		d.table.Responded(node)
+	})
+}
+
+func (d *DHT) send(addr netip.AddrPort, msg KRPCMessage) error {
+	packet, err := msg.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	_, err = d.conn.WriteTo(packet, net.UDPAddrFromAddrPort(addr))
+
+	return err
+}
+
+func (d *DHT) handleQuery(msg KRPCMessage, from netip.AddrPort) {
+	args := msg.Args
+	ret := KRPCReturn{ID: d.ID}
+
+	if name := missingArg(msg.Query, args); name != "" {
+		_ = d.send(from, NewKRPCErrorMessage(msg.TransactionID, KRPCProtocolError, "missing "+name))
+		return
+	}
+
+	switch msg.Query {
+	case QueryPing:
+
+	case QueryFindNode:
+		d.closestNodes(&ret, args.Target, from, args.Want)
+
+	case QueryGetPeers:
+		ret.Token = d.tokens.token(from.Addr())
+		ret.Values = d.peers.get(args.InfoHash, dhtMaxPeersPerHash, args.NoSeed)
+		if args.Scrape {
+			ret.SeedFilter, ret.PeerFilter = d.peers.scrape(args.InfoHash)
+		}
+
+		if len(ret.Values) == 0 {
+			d.closestNodes(&ret, NodeID(args.InfoHash), from, args.Want)
+		}
+
+	case QueryAnnouncePeer:
+		if !d.tokens.valid(args.Token, from.Addr()) {
+			_ = d.send(from, NewKRPCErrorMessage(msg.TransactionID, KRPCProtocolError, "bad token"))
+			return
+		}
+
+		port := from.Port()
+		if !args.ImpliedPort {
+			if args.Port <= 0 || args.Port > 0xffff {
+				_ = d.send(from, NewKRPCErrorMessage(msg.TransactionID, KRPCProtocolError, "invalid port"))
+				return
+			}
+
+			port = uint16(args.Port) //nolint: gosec // checked above
+		}
+
+		d.peers.add(args.InfoHash, netip.AddrPortFrom(from.Addr(), port), args.Seed)
+
+	case QuerySampleInfoHashes:
+		d.closestNodes(&ret, args.Target, from, args.Want)
+		samples, num := d.peers.sample(maxInfoHashSamples)
+		ret.Samples, ret.Num = &samples, &num
+		ret.Interval = int64(sampleInterval.Seconds())
+
+	case QueryGet:
+		d.handleGet(&ret, args, from)
+
+	case QueryPut:
+		if err := d.handlePut(args, from); err != nil {
+			krpcErr := KRPCError{KRPCProtocolError, err.Error()}
+			errors.As(err, &krpcErr)
+
+			_ = d.send(from, NewKRPCErrorMessage(msg.TransactionID, krpcErr.Code, krpcErr.Message))
+
+			return
+		}
+
+	default:
+		_ = d.send(from, NewKRPCErrorMessage(msg.TransactionID, KRPCMethodUnknown, "Method Unknown"))
+		return
+	}
+
+	resp := NewKRPCResponse(msg.TransactionID, ret)
+	resp.IP = from
+	_ = d.send(from, resp)
+
+	// read-only nodes (BEP 43) do not answer queries, so they are kept out of the table
+	if !msg.ReadOnly && !d.table.Queried(args.ID) {
+		d.goPing(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), d.timeout())
+			defer cancel()
+
+			_, _ = d.Ping(ctx, from)
+		})
+	}
+}
+
+// missingArg returns the name of an argument required by query but absent from args,
+// or an empty string when none is missing.
+func missingArg(query string, args *KRPCArgs) string {
+	// the id is required by every query, decoding already fails without it
+	switch query {
+	case QueryFindNode, QuerySampleInfoHashes, QueryGet:
+		if args.Target == (NodeID{}) {
+			return "target"
+		}
+
+	case QueryGetPeers, QueryAnnouncePeer:
+		if args.InfoHash == (InfoHash{}) {
+			return "info_hash"
+		}
+
+		if query == QueryAnnouncePeer && args.Token == "" {
+			return "token"
+		}
+
+	case QueryPut:
+		if args.Token == "" {
+			return "token"
+		}
+
+		if args.Value == nil {
+			return "v"
+		}
+	}
+
+	return ""
+}
+
+// goPing runs ping in the background, unless maxBackgroundPings are already running
+// in which case it is dropped.
+func (d *DHT) goPing(ping func()) {
+	select {
+	case d.pings <- struct{}{}:
+	default:
+		return
+	}
+
+	go func() {
+		defer func() { <-d.pings }()
+
+		ping()
+	}()
+}
+
+// closestNodes fills the nodes of ret for the address families in want,
+// or for the family of the querying node when want is empty (BEP 32).
+func (d *DHT) closestNodes(ret *KRPCReturn, target NodeID, from netip.AddrPort, want []string) {
+	wantIPv4, wantIPv6 := from.Addr().Is4(), from.Addr().Is6()
+	if len(want) > 0 {
+		wantIPv4, wantIPv6 = false, false
+	}
+
+	for _, family := range want {
+		wantIPv4 = wantIPv4 || family == "n4"
+		wantIPv6 = wantIPv6 || family == "n6"
+	}
+
+	for _, node := range d.table.Closest(target, d.table.Len()) {
+		switch {
+		case node.Addr.Addr().Is4() && wantIPv4 && len(ret.Nodes) < BucketSize:
+			ret.Nodes = append(ret.Nodes, node)
+		case node.Addr.Addr().Is6() && wantIPv6 && len(ret.Nodes6) < BucketSize:
+			ret.Nodes6 = append(ret.Nodes6, node)
+		}
+	}
+}
+
+// Ping queries the node at addr and returns its id.
+func (d *DHT) Ping(ctx context.Context, addr netip.AddrPort) (NodeID, error) {
+	resp, err := d.query(ctx, addr, NewPingQuery("", d.ID))
+	if err != nil {
+		return NodeID{}, err
+	}
+
+	return resp.Response.ID, nil
+}
+
+// Bootstrap pings the given nodes and looks up the own id to fill the routing table.
+func (d *DHT) Bootstrap(ctx context.Context, addrs ...netip.AddrPort) error {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		responded bool
+	)
+
+	for _, addr := range addrs {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := d.Ping(ctx, addr); err == nil {
+				mu.Lock()
+				responded = true
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if !responded {
+		return ErrNoDHTNodes
+	}
+
+	_, err := d.FindNode(ctx, d.ID)
+
+	return err
+}
+
+// Refresh looks up a random id in every bucket that did not change for 15 minutes,
+// it should be called periodically.
+func (d *DHT) Refresh(ctx context.Context) {
+	for _, target := range d.table.RefreshTargets(time.Now()) {
+		_, _ = d.FindNode(ctx, target)
+	}
+}
+
+// FindNode runs an iterative lookup and returns the nodes closest to target.
+func (d *DHT) FindNode(ctx context.Context, target NodeID) ([]NodeInfo, error) {
+	results, err := d.lookup(ctx, target, NewFindNodeQuery("", d.ID, target), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]NodeInfo, 0, len(results))
+	for _, result := range results {
+		nodes = append(nodes, result.node)
+	}
+
+	return nodes, nil
+}
+
+// GetPeers runs an iterative lookup and returns the peers found for infoHash.
+func (d *DHT) GetPeers(ctx context.Context, infoHash InfoHash) ([]netip.AddrPort, error) {
+	peers, _, err := d.getPeers(ctx, infoHash)
+	return peers, err
+}
+
+// Announce looks up infoHash, then announces port to the closest nodes,
+// a zero port lets them use the source port of the query. The peers found are returned.
+func (d *DHT) Announce(ctx context.Context, infoHash InfoHash, port uint16) ([]netip.AddrPort, error) {
+	return d.announce(ctx, infoHash, port, false)
+}
+
+// AnnounceSeed is like Announce, for peers having the whole torrent (BEP 33).
+func (d *DHT) AnnounceSeed(ctx context.Context, infoHash InfoHash, port uint16) ([]netip.AddrPort, error) {
+	return d.announce(ctx, infoHash, port, true)
+}
+
+func (d *DHT) announce(ctx context.Context, infoHash InfoHash, port uint16, seed bool) ([]netip.AddrPort, error) {
+	peers, results, err := d.getPeers(ctx, infoHash)
+	if err != nil {
+		return peers, err
+	}
+
+	announced := d.queryWithTokens(ctx, results, func(token string) KRPCMessage {
+		msg := NewAnnouncePeerQuery("", d.ID, infoHash, port, token)
+		msg.Args.Seed = seed
+
+		return msg
+	})
+	if !announced {
+		return peers, ErrNotAnnounced
+	}
+
+	return peers, nil
+}
+
+// queryWithTokens sends a query to every lookup result that handed out a token,
+// and reports whether any of them accepted it.
+func (d *DHT) queryWithTokens(ctx context.Context, results []lookupResult, newQuery func(string) KRPCMessage) bool {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted bool
+	)
+
+	for _, result := range results {
+		if result.token == "" {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := d.query(ctx, result.node.Addr, newQuery(result.token)); err == nil {
+				mu.Lock()
+				accepted = true
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return accepted
+}
+
+func (d *DHT) getPeers(ctx context.Context, infoHash InfoHash) ([]netip.AddrPort, []lookupResult, error) {
+	var (
+		peers []netip.AddrPort
+		seen  = make(map[netip.AddrPort]bool)
+	)
+
+	query := NewGetPeersQuery("", d.ID, infoHash)
+
+	results, err := d.lookup(ctx, NodeID(infoHash), query, func(resp KRPCReturn) {
+		for _, peer := range resp.Values {
+			if !seen[peer] {
+				seen[peer] = true
+				peers = append(peers, peer)
+			}
+		}
+	})
+
+	return peers, results, err
+}
+
+type lookupResult struct {
+	node  NodeInfo
+	token string
+}
+
+type lookupCandidate struct {
+	node      NodeInfo
+	queried   bool
+	responded bool
+	failed    bool
+	token     string
+}
+
+type lookupReply struct {
+	candidate *lookupCandidate
+	resp      KRPCMessage
+	err       error
+}
+
+// lookup queries the nodes closest to target, lookupAlpha at a time, getting closer with
+// every response, until the BucketSize closest nodes have all been queried.
+// The closest nodes that responded are returned, onResponse is called for every response
+// when not nil.
+func (d *DHT) lookup(
+	ctx context.Context,
+	target NodeID,
+	query KRPCMessage,
+	onResponse func(KRPCReturn),
+) ([]lookupResult, error) {
+	var (
+		candidates []*lookupCandidate
+		known      = make(map[netip.AddrPort]bool)
+		inflight   int
+		replies    = make(chan lookupReply)
+	)
+
+	addCandidates := func(nodes []NodeInfo) {
+		for _, node := range nodes {
+			if node.ID == d.ID || known[node.Addr] {
+				continue
+			}
+
+			known[node.Addr] = true
+			candidates = append(candidates, &lookupCandidate{node: node})
+		}
+
+		sortCandidates(candidates, target)
+	}
+
+	addCandidates(d.table.Closest(target, BucketSize))
+
+	if len(candidates) == 0 {
+		return nil, ErrNoDHTNodes
+	}
+
+	for {
+		var considered int
+
+		for _, candidate := range candidates {
+			if considered == BucketSize || inflight == lookupAlpha {
+				break
+			}
+
+			if candidate.failed {
+				continue
+			}
+
+			considered++
+
+			if candidate.queried {
+				continue
+			}
+
+			candidate.queried = true
+			inflight++
+
+			go func() {
+				resp, err := d.query(ctx, candidate.node.Addr, query)
+				replies <- lookupReply{candidate: candidate, resp: resp, err: err}
+			}()
+		}
+
+		if inflight == 0 {
+			break
+		}
+
+		reply := <-replies
+		inflight--
+
+		if reply.err != nil || reply.resp.Response == nil {
+			reply.candidate.failed = true
+			continue
+		}
+
+		ret := reply.resp.Response
+		reply.candidate.responded = true
+		reply.candidate.node.ID = ret.ID
+		reply.candidate.token = ret.Token
+
+		if onResponse != nil {
+			onResponse(*ret)
+		}
+
+		addCandidates(append(ret.Nodes, ret.Nodes6...))
+	}
+
+	var results []lookupResult
+
+	for _, candidate := range candidates {
+		if candidate.responded && len(results) < BucketSize {
+			results = append(results, lookupResult{node: candidate.node, token: candidate.token})
+		}
+	}
+
+	if len(results) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrNoDHTNodes
+	}
+
+	return results, nil
+}
+
+func sortCandidates(candidates []*lookupCandidate, target NodeID) {
+	slices.SortFunc(candidates, func(a, b *lookupCandidate) int {
+		return compareDistance(a.node.ID, b.node.ID, target)
+	})
+}
+
+// tokenSecrets derives get_peers tokens from the querying ip and a rotating secret.
+type tokenSecrets struct {
+	mu      sync.Mutex
+	current [16]byte
+	prev    [16]byte
+	rotated time.Time
+}
+
+func (s *tokenSecrets) rotate(now time.Time) {
+	elapsed := now.Sub(s.rotated)
+	if !s.rotated.IsZero() && elapsed < tokenRotation {
+		return
+	}
+
+	s.prev = s.current
+	_, _ = rand.Read(s.current[:])
+
+	// the previous secret is too old to be accepted after a long idle time
+	if s.rotated.IsZero() || elapsed >= 2*tokenRotation {
+		s.prev = s.current
+	}
+
+	s.rotated = now
+}
+
+func (s *tokenSecrets) token(addr netip.Addr) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rotate(time.Now())
+
+	return tokenFor(s.current, addr)
+}
+
+func (s *tokenSecrets) valid(token string, addr netip.Addr) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rotate(time.Now())
+
+	return token == tokenFor(s.current, addr) || token == tokenFor(s.prev, addr)
+}
+
+func tokenFor(secret [16]byte, addr netip.Addr) string {
+	sum := sha1.Sum(append(addr.Unmap().AsSlice(), secret[:]...)) //nolint: gosec // see import
+	return string(sum[:8])
+}
+
+// peerStore keeps the peers announced to this node.
+type peerStore struct {
+	mu     sync.Mutex
+	swarms map[InfoHash]map[netip.AddrPort]storedPeer
+	now    func() time.Time
+}
+
+type storedPeer struct {
+	announced time.Time
+	seed      bool
+}
+
+// add stores peer, unless the store or the swarm of infoHash is full.
+func (s *peerStore) add(infoHash InfoHash, peer netip.AddrPort, seed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	peers := s.live(infoHash)
+	if peers == nil {
+		if len(s.swarms) >= dhtMaxStoredSwarms {
+			s.expire()
+		}
+
+		if len(s.swarms) >= dhtMaxStoredSwarms {
+			return
+		}
+
+		peers = make(map[netip.AddrPort]storedPeer)
+		s.swarms[infoHash] = peers
+	}
+
+	if _, ok := peers[peer]; !ok && len(peers) >= dhtMaxStoredPeers {
+		return
+	}
+
+	peers[peer] = storedPeer{announced: s.now(), seed: seed}
+}
+
+// expire drops the expired peers of every swarm, s.mu must be held.
+func (s *peerStore) expire() {
+	for infoHash := range s.swarms {
+		s.live(infoHash)
+	}
+}
+
+// live drops the expired peers of infoHash and returns the others, or nil once the swarm
+// is dropped as empty. s.mu must be held.
+func (s *peerStore) live(infoHash InfoHash) map[netip.AddrPort]storedPeer {
+	peers := s.swarms[infoHash]
+	now := s.now()
+
+	for peer, stored := range peers {
+		if now.Sub(stored.announced) > dhtPeerTTL {
+			delete(peers, peer)
+		}
+	}
+
+	if len(peers) == 0 {
+		delete(s.swarms, infoHash)
+		return nil
+	}
+
+	return peers
+}
+
+// get returns up to n peers of infoHash, seeds are left out when noSeed is set (BEP 33).
+func (s *peerStore) get(infoHash InfoHash, n int, noSeed bool) PeerValues {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var values PeerValues
+
+	for peer, stored := range s.live(infoHash) {
+		if len(values) < n && !(noSeed && stored.seed) {
+			values = append(values, peer)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,158 @@
+package ben
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"net/netip"
+	"reflect"
+	"time"
+)
+
+const (
+	// maxInfoHashSamples keeps sample_infohashes responses within a single packet.
+	maxInfoHashSamples = 20
+
+	// sampleInterval is how long requesters should wait before sampling again,
+	// capped at 6 hours by BEP 51.
+	sampleInterval = 6 * time.Hour
+)
+
+// InfoHashes is a list of info-hashes sent as their concatenation, as in the `samples` of BEP 51.
+type InfoHashes []InfoHash
+
+// InfoHashSamples is the response to a sample_infohashes query.
+type InfoHashSamples struct {
+	// Interval is how long to wait before querying the same node again.
+	Interval time.Duration
+	// Num is the number of info-hashes stored by the node.
+	Num     int64
+	Samples InfoHashes
+	Nodes   []NodeInfo
+}
+
+// SwarmSize is the estimated size of a swarm, as scraped from the DHT.
+type SwarmSize struct {
+	Seeds int64
+	Peers int64
+}
+
+// scrape returns the bloom filters of the seeds and the peers of infoHash.
+func (s *peerStore) scrape(infoHash InfoHash) (*BloomFilter, *BloomFilter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var seeds, peers BloomFilter
+
+	for peer, stored := range s.live(infoHash) {
+		if stored.seed {
+			seeds.Insert(peer.Addr())
+		} else {
+			peers.Insert(peer.Addr())
+		}
+	}
+
+	return &seeds, &peers
+}
+
+// sample returns up to n random info-hashes having live peers, along with their total count.
+func (s *peerStore) sample(n int) (InfoHashes, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var hashes InfoHashes
+
+	for infoHash := range s.swarms {
+		if len(s.live(infoHash)) > 0 {
+			hashes = append(hashes, infoHash)
+		}
+	}
+
+	rand.Shuffle(len(hashes), func(i, j int) {
+		hashes[i], hashes[j] = hashes[j], hashes[i]
+	})
+
+	return hashes[:min(n, len(hashes))], int64(len(hashes))
+}
+
+// SampleInfoHashes asks the node at addr for a sample of the info-hashes it stores,
+// along with the nodes closest to target to continue the traversal of the DHT (BEP 51).
+func (d *DHT) SampleInfoHashes(ctx context.Context, addr netip.AddrPort, target NodeID) (InfoHashSamples, error) {
+	resp, err := d.query(ctx, addr, NewSampleInfoHashesQuery("", d.ID, target))
+	if err != nil {
+		return InfoHashSamples{}, err
+	}
+
+	ret := resp.Response
+	samples := InfoHashSamples{
+		Interval: time.Duration(ret.Interval) * time.Second,
+		Nodes:    append(ret.Nodes, ret.Nodes6...),
+	}
+
+	if ret.Num != nil {
+		samples.Num = *ret.Num
+	}
+
+	if ret.Samples != nil {
+		samples.Samples = *ret.Samples
+	}
+
+	return samples, nil
+}
+
+// Scrape estimates the number of seeds and peers of infoHash from the bloom filters
+// returned by the nodes closest to it (BEP 33).
+func (d *DHT) Scrape(ctx context.Context, infoHash InfoHash) (SwarmSize, error) {
+	var seeds, peers BloomFilter
+
+	query := NewScrapeQuery("", d.ID, infoHash, false)
+
+	_, err := d.lookup(ctx, NodeID(infoHash), query, func(ret KRPCReturn) {
+		if ret.SeedFilter != nil {
+			seeds.Union(ret.SeedFilter)
+		}
+
+		if ret.PeerFilter != nil {
+			peers.Union(ret.PeerFilter)
+		}
+	})
+	if err != nil {
+		return SwarmSize{}, err
+	}
+
+	return SwarmSize{
+		Seeds: int64(math.Round(seeds.Estimate())),
+		Peers: int64(math.Round(peers.Estimate())),
+	}, nil
+}
+
+func benInfoHashesStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	compact, err := l.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if len(compact)%len(InfoHash{}) != 0 {
+		return ErrInvalidKRPCMessage
+	}
+
+	hashes := make(InfoHashes, len(compact)/len(InfoHash{}))
+	for i := range hashes {
+		copy(hashes[i][:], compact[i*len(InfoHash{}):])
+	}
+
+	obj.Set(reflect.ValueOf(hashes))
+
+	return nil
+}
+
+func benInfoHashesStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	hashes, _ := obj.Interface().(InfoHashes)
+
+	compact := make([]byte, 0, len(hashes)*len(InfoHash{}))
+	for _, hash := range hashes {
+		compact = append(compact, hash[:]...)
+	}
+
+	return Str(string(compact)), nil
+}
@@ -0,0 +1,150 @@
+package ben_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bloom filter", func() {
+	It("estimates the size of the BEP 33 test vector", func() {
+		var filter ben.BloomFilter
+
+		for i := range 256 {
+			filter.Insert(netip.MustParseAddr(fmt.Sprintf("192.0.2.%d", i)))
+		}
+
+		for i := range 1000 {
+			filter.Insert(netip.MustParseAddr(fmt.Sprintf("2001:db8::%x", i)))
+		}
+
+		Expect(filter.Contains(netip.MustParseAddr("192.0.2.42"))).To(BeTrue())
+		Expect(filter.Estimate()).To(BeNumerically("~", 1224.93, 0.01))
+	})
+
+	It("merges filters", func() {
+		var first, second ben.BloomFilter
+
+		first.Insert(netip.MustParseAddr("10.0.0.1"))
+		second.Insert(netip.MustParseAddr("10.0.0.2"))
+		first.Union(&second)
+
+		Expect(first.Contains(netip.MustParseAddr("10.0.0.2"))).To(BeTrue())
+		Expect(first.Estimate()).To(BeNumerically("~", 2, 0.01))
+	})
+})
+
+var _ = Describe("DHT scrape and sampling messages", func() {
+	It("round-trips scrape responses", func() {
+		var seeds ben.BloomFilter
+		seeds.Insert(netip.MustParseAddr("10.0.0.1"))
+
+		msg := ben.NewKRPCResponse("aa", ben.KRPCReturn{ID: ben.NodeID{1}, SeedFilter: &seeds, PeerFilter: &ben.BloomFilter{}})
+
+		raw, err := msg.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(raw)).To(ContainSubstring("4:BFsd256:"))
+
+		decoded, err := ben.ParseKRPCMessage(raw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*decoded.Response.SeedFilter).To(Equal(seeds))
+		Expect(*decoded.Response.PeerFilter).To(Equal(ben.BloomFilter{}))
+	})
+
+	It("round-trips info-hash samples", func() {
+		samples, num := ben.InfoHashes{{1}, {2}, {3}}, int64(3)
+		msg := ben.NewKRPCResponse("aa", ben.KRPCReturn{ID: ben.NodeID{1}, Interval: 60, Num: &num, Samples: &samples})
+
+		raw, err := msg.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(raw)).To(ContainSubstring("7:samples60:"))
+
+		decoded, err := ben.ParseKRPCMessage(raw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*decoded.Response.Samples).To(Equal(samples))
+		Expect(decoded.Response.Interval).To(Equal(int64(60)))
+		Expect(*decoded.Response.Num).To(Equal(int64(3)))
+	})
+
+	It("keeps num and samples when no info-hash is stored", func() {
+		samples, num := ben.InfoHashes{}, int64(0)
+		msg := ben.NewKRPCResponse("aa", ben.KRPCReturn{ID: ben.NodeID{1}, Interval: 60, Num: &num, Samples: &samples})
+
+		raw, err := msg.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(raw)).To(ContainSubstring("3:numi0e7:samples0:"))
+
+		raw, err = ben.NewKRPCResponse("aa", ben.KRPCReturn{ID: ben.NodeID{1}}).MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(raw)).NotTo(ContainSubstring("samples"))
+	})
+
+	It("encodes the scrape flags of get_peers", func() {
+		raw, err := ben.NewScrapeQuery("aa", ben.NodeID{1}, ben.InfoHash{2}, true).MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(raw)).To(ContainSubstring("6:noseedi1e6:scrapei1e"))
+	})
+})
+
+var _ = Describe("DHT scrape and sampling", func() {
+	var (
+		nodes []*ben.DHT
+		conns []net.PacketConn
+		ctx   context.Context
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		nodes, conns = startDHTNetwork(6)
+	})
+
+	It("scrapes seeds and peers", func() {
+		hash := ben.InfoHash(ben.RandomNodeID())
+
+		_, err := nodes[1].AnnounceSeed(ctx, hash, 7000)
+		Expect(err).NotTo(HaveOccurred())
+
+		size, err := nodes[4].Scrape(ctx, hash)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(size).To(Equal(ben.SwarmSize{Seeds: 1, Peers: 0}))
+
+		_, err = nodes[2].Announce(ctx, hash, 7001)
+		Expect(err).NotTo(HaveOccurred())
+
+		size, err = nodes[4].Scrape(ctx, hash)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(size).To(Equal(ben.SwarmSize{Seeds: 1, Peers: 1}))
+	})
+
+	It("samples stored info-hashes", func() {
+		hash := ben.InfoHash(ben.RandomNodeID())
+
+		_, err := nodes[1].Announce(ctx, hash, 7000)
+		Expect(err).NotTo(HaveOccurred())
+
+		var found bool
+
+		for _, conn := range conns {
+			samples, err := nodes[5].SampleInfoHashes(ctx, dhtAddr(conn), ben.RandomNodeID())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(samples.Interval.Hours()).To(Equal(6.0))
+			Expect(samples.Samples).To(HaveLen(int(samples.Num)))
+
+			if len(samples.Samples) > 0 {
+				Expect(samples.Samples).To(Equal(ben.InfoHashes{hash}))
+				found = true
+			}
+		}
+
+		Expect(found).To(BeTrue())
+	})
+})
@@ -0,0 +1,424 @@
+package ben
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha1" //nolint: gosec // mandated by BEP 44
+	"errors"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+const (
+	maxItemSize = 1000
+	maxSaltSize = 64
+
+	// items expire when they are not republished within 2 hours (BEP 44).
+	itemTTL = 2 * time.Hour
+
+	defaultMaxItems = 10000
+)
+
+// PublicKey is the ed25519 public key of a mutable item.
+type PublicKey [ed25519.PublicKeySize]byte
+
+// Signature is the ed25519 signature of a mutable item.
+type Signature [ed25519.SignatureSize]byte
+
+// ImmutableTarget returns the DHT key of an immutable item, the SHA-1 of its bencoded value.
+func ImmutableTarget(value Element) NodeID {
+	return sha1.Sum(value.Encode()) //nolint: gosec // see import
+}
+
+// MutableTarget returns the DHT key of a mutable item, the SHA-1 of its public key and salt.
+func MutableTarget(key PublicKey, salt string) NodeID {
+	return sha1.Sum(append(key[:], salt...)) //nolint: gosec // see import
+}
+
+// validateImmutable checks the size of an immutable item value.
+func validateImmutable(value Element) error {
+	if value == nil {
+		return ErrInvalidKRPCMessage
+	}
+
+	if len(value.Encode()) > maxItemSize {
+		return ErrItemTooBig
+	}
+
+	return nil
+}
+
+// MutableItem is a value signed by the owner of Key, a higher Seq supersedes older values.
+type MutableItem struct {
+	Key       PublicKey
+	Salt      string
+	Seq       int64
+	Value     Element
+	Signature Signature
+}
+
+// NewMutableItem signs value with key.
+func NewMutableItem(key ed25519.PrivateKey, salt string, seq int64, value Element) MutableItem {
+	item := MutableItem{Salt: salt, Seq: seq, Value: value}
+	copy(item.Key[:], key.Public().(ed25519.PublicKey)) //nolint: forcetypeassert // always ed25519
+	copy(item.Signature[:], ed25519.Sign(key, item.SignedData()))
+
+	return item
+}
+
+func (m MutableItem) Target() NodeID {
+	return MutableTarget(m.Key, m.Salt)
+}
+
+// SignedData returns the bytes covered by the signature, the bencoded `salt`, `seq` and `v`
+// entries of a dictionary without the surrounding `d` and `e`.
+func (m MutableItem) SignedData() []byte {
+	var data []byte
+
+	if m.Salt != "" {
+		data = append(data, Str("salt").Encode()...)
+		data = append(data, Str(m.Salt).Encode()...)
+	}
+
+	data = append(data, Str("seq").Encode()...)
+	data = append(data, Int(m.Seq).Encode()...)
+	data = append(data, Str("v").Encode()...)
+
+	return append(data, m.Value.Encode()...)
+}
+
+// Validate checks the sizes and the signature of the item.
+func (m MutableItem) Validate() error {
+	if len(m.Salt) > maxSaltSize {
+		return ErrSaltTooBig
+	}
+
+	if err := validateImmutable(m.Value); err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(m.Key[:], m.SignedData(), m.Signature[:]) {
+		return ErrInvalidItemSignature
+	}
+
+	return nil
+}
+
+func NewImmutableGetQuery(tid string, id, target NodeID) KRPCMessage {
+	return newQuery(tid, QueryGet, KRPCArgs{ID: id, Target: target})
+}
+
+// NewMutableGetQuery asks for the item under target, the value is only returned when it
+// is newer than seq, when given.
+func NewMutableGetQuery(tid string, id, target NodeID, seq *int64) KRPCMessage {
+	return newQuery(tid, QueryGet, KRPCArgs{ID: id, Target: target, Seq: seq})
+}
+
+func NewImmutablePutQuery(tid string, id NodeID, token string, value Element) KRPCMessage {
+	return newQuery(tid, QueryPut, KRPCArgs{ID: id, Token: token, Value: value})
+}
+
+// NewMutablePutQuery stores item, cas is the sequence number the item must replace when given.
+func NewMutablePutQuery(tid string, id NodeID, token string, item MutableItem, cas *int64) KRPCMessage {
+	return newQuery(tid, QueryPut, KRPCArgs{
+		ID:        id,
+		Token:     token,
+		Value:     item.Value,
+		Key:       item.Key,
+		Salt:      item.Salt,
+		Seq:       &item.Seq,
+		CAS:       cas,
+		Signature: item.Signature,
+	})
+}
+
+// mutableItemFrom returns the mutable item carried by put arguments or get return values.
+func mutableItemFrom(key PublicKey, salt string, seq *int64, value Element, sig Signature) (MutableItem, error) {
+	if seq == nil || value == nil {
+		return MutableItem{}, ErrInvalidKRPCMessage
+	}
+
+	item := MutableItem{Key: key, Salt: salt, Seq: *seq, Value: value, Signature: sig}
+
+	return item, item.Validate()
+}
+
+type storedItem struct {
+	MutableItem
+
+	mutable  bool
+	storedAt time.Time
+}
+
+// ItemStore keeps the items put on this node. It is safe for concurrent use.
+type ItemStore struct {
+	// MaxItems bounds the number of stored items, defaults to 10000.
+	MaxItems int
+
+	mu    sync.Mutex
+	items map[NodeID]storedItem
+}
+
+func NewItemStore() *ItemStore {
+	return &ItemStore{items: make(map[NodeID]storedItem)}
+}
+
+// PutImmutable stores value and returns its target.
+func (s *ItemStore) PutImmutable(value Element) (NodeID, error) {
+	if err := validateImmutable(value); err != nil {
+		return NodeID{}, err
+	}
+
+	target := ImmutableTarget(value)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return target, s.put(target, storedItem{MutableItem: MutableItem{Value: value}, storedAt: time.Now()})
+}
+
+// PutMutable stores a validated item unless a newer one is stored,
+// or the stored sequence number differs from cas when given.
+func (s *ItemStore) PutMutable(item MutableItem, cas *int64) error {
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := item.Target()
+
+	current, ok := s.get(target)
+	if ok && cas != nil && current.Seq != *cas {
+		return ErrCASMismatch
+	}
+
+	if ok && item.Seq < current.Seq {
+		return ErrSeqTooLow
+	}
+
+	return s.put(target, storedItem{MutableItem: item, mutable: true, storedAt: time.Now()})
+}
+
+// Immutable returns the immutable value stored under target.
+func (s *ItemStore) Immutable(target NodeID) (Element, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.get(target)
+	if !ok || stored.mutable {
+		return nil, false
+	}
+
+	return stored.Value, true
+}
+
+// Mutable returns the mutable item stored under target.
+func (s *ItemStore) Mutable(target NodeID) (MutableItem, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.get(target)
+	if !ok || !stored.mutable {
+		return MutableItem{}, false
+	}
+
+	return stored.MutableItem, true
+}
+
+// Expire removes the items not republished within 2 hours before now. Items are otherwise
+// only pruned when accessed or when the store is full, so this should be called periodically.
+func (s *ItemStore) Expire(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(now)
+}
+
+func (s *ItemStore) expire(now time.Time) {
+	for target, stored := range s.items {
+		if now.Sub(stored.storedAt) > itemTTL {
+			delete(s.items, target)
+		}
+	}
+}
+
+func (s *ItemStore) maxItems() int {
+	if s.MaxItems <= 0 {
+		return defaultMaxItems
+	}
+
+	return s.MaxItems
+}
+
+// put stores item under target, new targets are refused once the store is full
+// of unexpired items. s.mu must be held.
+func (s *ItemStore) put(target NodeID, item storedItem) error {
+	if _, ok := s.items[target]; !ok && len(s.items) >= s.maxItems() {
+		s.expire(time.Now())
+
+		if len(s.items) >= s.maxItems() {
+			return ErrItemStoreFull
+		}
+	}
+
+	s.items[target] = item
+
+	return nil
+}
+
+func (s *ItemStore) get(target NodeID) (storedItem, bool) {
+	stored, ok := s.items[target]
+	if ok && time.Since(stored.storedAt) > itemTTL {
+		delete(s.items, target)
+		return storedItem{}, false
+	}
+
+	return stored, ok
+}
+
+// handleGet fills ret with the item stored under target along with a token for a later put.
+func (d *DHT) handleGet(ret *KRPCReturn, args *KRPCArgs, from netip.AddrPort) {
+	ret.Token = d.tokens.token(from.Addr())
+	d.closestNodes(ret, args.Target, from, args.Want)
+
+	if value, ok := d.items.Immutable(args.Target); ok {
+		ret.Value = value
+		return
+	}
+
+	item, ok := d.items.Mutable(args.Target)
+	if !ok {
+		return
+	}
+
+	ret.Key = item.Key
+	ret.Seq = &item.Seq
+	ret.Signature = item.Signature
+
+	if args.Seq == nil || *args.Seq < item.Seq {
+		ret.Value = item.Value
+	}
+}
+
+func (d *DHT) handlePut(args *KRPCArgs, from netip.AddrPort) error {
+	if !d.tokens.valid(args.Token, from.Addr()) {
+		return KRPCError{KRPCProtocolError, "bad token"}
+	}
+
+	if args.Key == (PublicKey{}) {
+		_, err := d.items.PutImmutable(args.Value)
+		return err
+	}
+
+	item, err := mutableItemFrom(args.Key, args.Salt, args.Seq, args.Value, args.Signature)
+	if err != nil {
+		return err
+	}
+
+	return d.items.PutMutable(item, args.CAS)
+}
+
+// GetImmutable looks up the immutable item under target.
+func (d *DHT) GetImmutable(ctx context.Context, target NodeID) (Element, error) {
+	var value Element
+
+	_, err := d.lookup(ctx, target, NewImmutableGetQuery("", d.ID, target), func(ret KRPCReturn) {
+		if value == nil && ret.Value != nil && ImmutableTarget(ret.Value) == target {
+			value = ret.Value
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, ErrItemNotFound
+	}
+
+	return value, nil
+}
+
+// GetMutable looks up the mutable item of key and salt, and returns the one with the highest
+// sequence number among the validly signed ones.
+func (d *DHT) GetMutable(ctx context.Context, key PublicKey, salt string) (MutableItem, error) {
+	item, _, err := d.getMutable(ctx, key, salt)
+	return item, err
+}
+
+func (d *DHT) getMutable(ctx context.Context, key PublicKey, salt string) (MutableItem, []lookupResult, error) {
+	var (
+		latest MutableItem
+		found  bool
+		target = MutableTarget(key, salt)
+	)
+
+	query := NewMutableGetQuery("", d.ID, target, nil)
+
+	results, err := d.lookup(ctx, target, query, func(ret KRPCReturn) {
+		if ret.Key != key {
+			return
+		}
+
+		item, err := mutableItemFrom(key, salt, ret.Seq, ret.Value, ret.Signature)
+		if err == nil && (!found || item.Seq > latest.Seq) {
+			latest, found = item, true
+		}
+	})
+	if err != nil {
+		return latest, nil, err
+	}
+
+	if !found {
+		return latest, results, ErrItemNotFound
+	}
+
+	return latest, results, nil
+}
+
+// PutImmutable stores value on the nodes closest to its target, which is returned.
+func (d *DHT) PutImmutable(ctx context.Context, value Element) (NodeID, error) {
+	if err := validateImmutable(value); err != nil {
+		return NodeID{}, err
+	}
+
+	target := ImmutableTarget(value)
+
+	results, err := d.lookup(ctx, target, NewImmutableGetQuery("", d.ID, target), nil)
+	if err != nil {
+		return target, err
+	}
+
+	stored := d.queryWithTokens(ctx, results, func(token string) KRPCMessage {
+		return NewImmutablePutQuery("", d.ID, token, value)
+	})
+	if !stored {
+		return target, ErrNotStored
+	}
+
+	return target, nil
+}
+
+// PutMutable stores item on the nodes closest to its target, cas is the sequence number
+// the item must replace when given.
+func (d *DHT) PutMutable(ctx context.Context, item MutableItem, cas *int64) error {
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	_, results, err := d.getMutable(ctx, item.Key, item.Salt)
+	if err != nil && !errors.Is(err, ErrItemNotFound) {
+		return err
+	}
+
+	stored := d.queryWithTokens(ctx, results, func(token string) KRPCMessage {
+		return NewMutablePutQuery("", d.ID, token, item, cas)
+	})
+	if !stored {
+		return ErrNotStored
+	}
+
+	return nil
+}
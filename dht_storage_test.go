@@ -0,0 +1,202 @@
+package ben_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"time"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	Expect(err).NotTo(HaveOccurred())
+
+	return b
+}
+
+var _ = Describe("DHT storage", func() {
+	// test vectors from BEP 44
+	bep44Key := ben.PublicKey(mustDecodeHex("77ff84905a91936367c01360803104f92432fcd904a43511876df5cdf3e7e548"))
+
+	It("computes the target of immutable items", func() {
+		target := ben.ImmutableTarget(ben.Str("Hello World!"))
+		Expect(target.String()).To(Equal("e5f96f6f38320f0f33959cb4d3d656452117aadb"))
+	})
+
+	It("verifies the BEP 44 mutable item without salt", func() {
+		item := ben.MutableItem{
+			Key:   bep44Key,
+			Seq:   1,
+			Value: ben.Str("Hello World!"),
+			Signature: ben.Signature(mustDecodeHex("305ac8aeb6c9c151fa120f120ea2cfb923564e11552d06a5d856091e5e853cff" +
+				"1260d3f39e4999684aa92eb73ffd136e6f4f3ecbfda0ce53a1608ecd7ae21f01")),
+		}
+
+		Expect(string(item.SignedData())).To(Equal("3:seqi1e1:v12:Hello World!"))
+		Expect(item.Validate()).To(Succeed())
+		Expect(item.Target().String()).To(Equal("4a533d47ec9c7d95b1ad75f576cffc641853b750"))
+
+		item.Seq = 2
+		Expect(item.Validate()).To(MatchError(ben.ErrInvalidItemSignature))
+	})
+
+	It("verifies the BEP 44 mutable item with salt", func() {
+		item := ben.MutableItem{
+			Key:   bep44Key,
+			Salt:  "foobar",
+			Seq:   1,
+			Value: ben.Str("Hello World!"),
+			Signature: ben.Signature(mustDecodeHex("6834284b6b24c3204eb2fea824d82f88883a3d95e8b4a21b8c0ded553d17d17d" +
+				"df9a8a7104b1258f30bed3787e6cb896fca78c58f8e03b5f18f14951a87d9a08")),
+		}
+
+		Expect(string(item.SignedData())).To(Equal("4:salt6:foobar3:seqi1e1:v12:Hello World!"))
+		Expect(item.Validate()).To(Succeed())
+		Expect(item.Target().String()).To(Equal("411eba73b6f087ca51a3795d9c8c938d365e32c1"))
+	})
+
+	It("signs items over canonically encoded values", func() {
+		_, key, err := ed25519.GenerateKey(nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		value := ben.Dct(map[string]ben.Element{"b": ben.Int(2), "a": ben.Lst([]ben.Element{ben.Str("x")})})
+		item := ben.NewMutableItem(key, "config", 7, value)
+
+		Expect(item.Validate()).To(Succeed())
+		Expect(string(item.SignedData())).To(Equal("4:salt6:config3:seqi7e1:vd1:al1:xe1:bi2ee"))
+		Expect(item.Key[:]).To(BeEquivalentTo(key.Public()))
+	})
+
+	It("rejects oversized salts and values", func() {
+		_, key, err := ed25519.GenerateKey(nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		item := ben.NewMutableItem(key, string(make([]byte, 65)), 1, ben.Int(1))
+		Expect(item.Validate()).To(MatchError(ben.ErrSaltTooBig))
+
+		item = ben.NewMutableItem(key, "", 1, ben.Str(string(make([]byte, 1000))))
+		Expect(item.Validate()).To(MatchError(ben.ErrItemTooBig))
+
+		_, err = ben.NewItemStore().PutImmutable(ben.Str(string(make([]byte, 1000))))
+		Expect(err).To(MatchError(ben.ErrItemTooBig))
+	})
+
+	It("stores mutable items with sequence and CAS checks", func() {
+		_, key, err := ed25519.GenerateKey(nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		store := ben.NewItemStore()
+		first := ben.NewMutableItem(key, "", 1, ben.Str("v1"))
+		second := ben.NewMutableItem(key, "", 2, ben.Str("v2"))
+
+		Expect(store.PutMutable(first, nil)).To(Succeed())
+		Expect(store.PutMutable(second, new(int64))).To(MatchError(ben.ErrCASMismatch))
+
+		cas := int64(1)
+		Expect(store.PutMutable(second, &cas)).To(Succeed())
+		Expect(store.PutMutable(first, nil)).To(MatchError(ben.ErrSeqTooLow))
+
+		stored, ok := store.Mutable(second.Target())
+		Expect(ok).To(BeTrue())
+		Expect(stored).To(Equal(second))
+
+		_, ok = store.Immutable(second.Target())
+		Expect(ok).To(BeFalse())
+
+		forged := second
+		forged.Value = ben.Str("v3")
+		Expect(store.PutMutable(forged, nil)).To(MatchError(ben.ErrInvalidItemSignature))
+	})
+
+	It("refuses new items once full until older ones expire", func() {
+		store := ben.NewItemStore()
+		store.MaxItems = 2
+
+		_, err := store.PutImmutable(ben.Int(1))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = store.PutImmutable(ben.Int(2))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = store.PutImmutable(ben.Int(3))
+		Expect(err).To(MatchError(ben.ErrItemStoreFull))
+
+		// republishing a stored item is still accepted
+		_, err = store.PutImmutable(ben.Int(1))
+		Expect(err).NotTo(HaveOccurred())
+
+		store.Expire(time.Now().Add(3 * time.Hour))
+		_, ok := store.Immutable(ben.ImmutableTarget(ben.Int(1)))
+		Expect(ok).To(BeFalse())
+
+		_, err = store.PutImmutable(ben.Int(3))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("encodes put queries", func() {
+		item := ben.MutableItem{Key: bep44Key, Salt: "foobar", Seq: 1, Value: ben.Str("Hello World!")}
+		cas := int64(0)
+
+		msg := ben.NewMutablePutQuery("aa", ben.NodeID{}, "tk", item, &cas)
+
+		encoded, err := msg.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(encoded)).To(ContainSubstring("3:casi0e"))
+		Expect(string(encoded)).To(ContainSubstring("4:salt6:foobar3:seqi1e"))
+		Expect(string(encoded)).To(ContainSubstring("1:v12:Hello World!"))
+
+		decoded, err := ben.ParseKRPCMessage(encoded)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded).To(Equal(msg))
+	})
+
+	Context("over the DHT", func() {
+		var nodes []*ben.DHT
+
+		BeforeEach(func() {
+			nodes, _ = startDHTNetwork(6)
+		})
+
+		It("puts and gets immutable items", func() {
+			value := ben.Lst([]ben.Element{ben.Str("hello"), ben.Int(42)})
+
+			target, err := nodes[1].PutImmutable(context.Background(), value)
+			Expect(err).NotTo(HaveOccurred())
+
+			got, err := nodes[4].GetImmutable(context.Background(), target)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got.Encode()).To(Equal(value.Encode()))
+
+			_, err = nodes[4].GetImmutable(context.Background(), ben.RandomNodeID())
+			Expect(err).To(MatchError(ben.ErrItemNotFound))
+		})
+
+		It("puts and gets the latest mutable item", func() {
+			pub, key, err := ed25519.GenerateKey(nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx := context.Background()
+
+			Expect(nodes[2].PutMutable(ctx, ben.NewMutableItem(key, "cfg", 1, ben.Str("one")), nil)).To(Succeed())
+			Expect(nodes[3].PutMutable(ctx, ben.NewMutableItem(key, "cfg", 2, ben.Str("two")), nil)).To(Succeed())
+
+			err = nodes[2].PutMutable(ctx, ben.NewMutableItem(key, "cfg", 3, ben.Str("three")), new(int64))
+			Expect(err).To(MatchError(ben.ErrNotStored))
+
+			item, err := nodes[5].GetMutable(ctx, ben.PublicKey(pub), "cfg")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(item.Seq).To(Equal(int64(2)))
+			Expect(item.Value).To(Equal(ben.Str("two")))
+
+			_, err = nodes[5].GetMutable(ctx, ben.PublicKey(pub), "other")
+			Expect(err).To(MatchError(ben.ErrItemNotFound))
+		})
+	})
+})
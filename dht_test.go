@@ -0,0 +1,322 @@
+package ben_test
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sync/atomic"
+	"time"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+func dhtAddr(conn net.PacketConn) netip.AddrPort {
+	return conn.LocalAddr().(*net.UDPAddr).AddrPort() //nolint: forcetypeassert // udp
+}
+
+// startDHTNetwork runs n nodes on loopback, all bootstrapped from the first one.
+func startDHTNetwork(n int) ([]*ben.DHT, []net.PacketConn) {
+	var (
+		nodes []*ben.DHT
+		conns []net.PacketConn
+	)
+
+	for range n {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		node := ben.NewDHT(conn, ben.RandomNodeID())
+		node.Timeout = 500 * time.Millisecond
+
+		go func() { _ = node.Serve() }()
+
+		nodes = append(nodes, node)
+		conns = append(conns, conn)
+	}
+
+	for _, node := range nodes[1:] {
+		Expect(node.Bootstrap(context.Background(), dhtAddr(conns[0]))).To(Succeed())
+	}
+
+	DeferCleanup(func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	})
+
+	return nodes, conns
+}
+
+func nodeWithPrefix(prefix byte, last byte) ben.NodeID {
+	var id ben.NodeID
+	id[0] = prefix
+	id[19] = last
+
+	return id
+}
+
+var _ = Describe("Routing table", func() {
+	var (
+		self  ben.NodeID
+		table *ben.RoutingTable
+	)
+
+	BeforeEach(func() {
+		self = nodeWithPrefix(0x00, 0)
+		table = ben.NewRoutingTable(self)
+	})
+
+	It("returns the closest nodes first", func() {
+		for i := range byte(4) {
+			node := ben.NodeInfo{
+				ID:   nodeWithPrefix(0x80>>i, 1),
+				Addr: netip.AddrPortFrom(netip.AddrFrom4([4]byte{10, 0, 0, i + 1}), 6881),
+			}
+			_, stale := table.Responded(node)
+			Expect(stale).To(BeFalse())
+		}
+
+		Expect(table.Len()).To(Equal(4))
+
+		closest := table.Closest(nodeWithPrefix(0x11, 0), 2)
+		Expect(closest).To(HaveLen(2))
+		Expect(closest[0].ID).To(Equal(nodeWithPrefix(0x10, 1)))
+		Expect(closest[1].ID).To(Equal(nodeWithPrefix(0x20, 1)))
+	})
+
+	It("never adds itself", func() {
+		table.Responded(ben.NodeInfo{ID: self, Addr: netip.MustParseAddrPort("10.0.0.1:1")})
+		Expect(table.Len()).To(BeZero())
+	})
+
+	It("replaces bad nodes of a full bucket", func() {
+		addrOf := func(i byte) netip.AddrPort {
+			return netip.AddrPortFrom(netip.AddrFrom4([4]byte{10, 0, 1, i}), 6881)
+		}
+
+		// all of them share no prefix bit with self, so they end up in the same bucket
+		for i := range byte(ben.BucketSize) {
+			table.Responded(ben.NodeInfo{ID: nodeWithPrefix(0x80, i), Addr: addrOf(i)})
+		}
+
+		newcomer := ben.NodeInfo{ID: nodeWithPrefix(0x80, 0xff), Addr: addrOf(0xff)}
+		_, stale := table.Responded(newcomer)
+		Expect(stale).To(BeFalse())
+		Expect(table.Len()).To(Equal(ben.BucketSize))
+
+		_, ok := table.State(newcomer.ID)
+		Expect(ok).To(BeFalse())
+
+		table.Failed(addrOf(3))
+		state, _ := table.State(nodeWithPrefix(0x80, 3))
+		Expect(state).To(Equal(ben.NodeGood))
+
+		table.Failed(addrOf(3))
+		state, _ = table.State(nodeWithPrefix(0x80, 3))
+		Expect(state).To(Equal(ben.NodeBad))
+		Expect(table.Closest(newcomer.ID, ben.BucketSize)).To(HaveLen(ben.BucketSize - 1))
+
+		table.Responded(newcomer)
+		state, ok = table.State(newcomer.ID)
+		Expect(ok).To(BeTrue())
+		Expect(state).To(Equal(ben.NodeGood))
+
+		_, ok = table.State(nodeWithPrefix(0x80, 3))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("refreshes buckets that did not change for 15 minutes", func() {
+		table.Responded(ben.NodeInfo{ID: nodeWithPrefix(0x10, 1), Addr: netip.MustParseAddrPort("10.0.0.1:1")})
+
+		Expect(table.RefreshTargets(time.Now())).To(BeEmpty())
+
+		targets := table.RefreshTargets(time.Now().Add(16 * time.Minute))
+		Expect(targets).To(HaveLen(1))
+		// bucket 3 holds ids starting with 0001
+		Expect(targets[0][0] & 0xf0).To(Equal(byte(0x10)))
+
+		Expect(table.RefreshTargets(time.Now().Add(16 * time.Minute))).To(BeEmpty())
+	})
+})
+
+var _ = Describe("DHT", func() {
+	var (
+		nodes []*ben.DHT
+		conns []net.PacketConn
+		ctx   context.Context
+	)
+
+	addrOf := dhtAddr
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		nodes, conns = startDHTNetwork(8)
+	})
+
+	It("pings other nodes", func() {
+		id, err := nodes[1].Ping(ctx, addrOf(conns[2]))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal(nodes[2].ID))
+	})
+
+	It("fills routing tables while bootstrapping", func() {
+		Eventually(func() int { return nodes[0].RoutingTable().Len() }).Should(Equal(len(nodes) - 1))
+
+		for _, node := range nodes[1:] {
+			Expect(node.RoutingTable().Len()).To(BeNumerically(">", 1))
+		}
+	})
+
+	It("finds the node closest to a target", func() {
+		found, err := nodes[7].FindNode(ctx, nodes[4].ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).NotTo(BeEmpty())
+		Expect(found[0].ID).To(Equal(nodes[4].ID))
+		Expect(found[0].Addr).To(Equal(addrOf(conns[4])))
+	})
+
+	It("announces and finds peers", func() {
+		hash := ben.InfoHash(ben.RandomNodeID())
+
+		peers, err := nodes[3].GetPeers(ctx, hash)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(peers).To(BeEmpty())
+
+		_, err = nodes[3].Announce(ctx, hash, 7000)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = nodes[5].Announce(ctx, hash, 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		peers, err = nodes[6].GetPeers(ctx, hash)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(peers).To(ContainElements(
+			netip.MustParseAddrPort("127.0.0.1:7000"),
+			addrOf(conns[5]),
+		))
+	})
+
+	It("stores announces again once every peer of a swarm expired", func() {
+		var elapsed atomic.Int64
+
+		ben.SetPeerClock(nodes[1], func() time.Time { return time.Now().Add(time.Duration(elapsed.Load())) })
+
+		client, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		defer func() { _ = client.Close() }()
+
+		query := func(msg ben.KRPCMessage) ben.KRPCMessage {
+			raw, err := msg.MarshalBinary()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = client.WriteTo(raw, net.UDPAddrFromAddrPort(addrOf(conns[1])))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+
+			// the node may query the client too, e.g. to ping it
+			for {
+				buff := make([]byte, 1500)
+				n, _, err := client.ReadFrom(buff)
+				Expect(err).NotTo(HaveOccurred())
+
+				resp, err := ben.ParseKRPCMessage(buff[:n])
+				Expect(err).NotTo(HaveOccurred())
+
+				if resp.TransactionID == msg.TransactionID {
+					Expect(resp.Err()).NotTo(HaveOccurred())
+					return resp
+				}
+			}
+		}
+
+		id, hash := ben.RandomNodeID(), ben.InfoHash(ben.RandomNodeID())
+		token := query(ben.NewGetPeersQuery("aa", id, hash)).Response.Token
+
+		query(ben.NewAnnouncePeerQuery("ab", id, hash, 7000, token))
+
+		// the swarm has nothing but expired peers when the next announce comes in
+		elapsed.Store(int64(time.Hour))
+		query(ben.NewAnnouncePeerQuery("ac", id, hash, 7001, token))
+
+		values := query(ben.NewGetPeersQuery("ad", id, hash)).Response.Values
+		Expect(values).To(ConsistOf(netip.MustParseAddrPort("127.0.0.1:7001")))
+	})
+
+	It("rejects announces with a bad token", func() {
+		client, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		defer func() { _ = client.Close() }()
+
+		query, err := ben.NewAnnouncePeerQuery("xy", ben.RandomNodeID(), ben.InfoHash{1}, 7000, "bogus").MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.WriteTo(query, net.UDPAddrFromAddrPort(addrOf(conns[1])))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(client.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+
+		buff := make([]byte, 1500)
+		n, _, err := client.ReadFrom(buff)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := ben.ParseKRPCMessage(buff[:n])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.TransactionID).To(Equal("xy"))
+		Expect(resp.Err()).To(MatchError(ben.KRPCError{Code: ben.KRPCProtocolError, Message: "bad token"}))
+	})
+
+	DescribeTable("rejects queries missing a required argument",
+		func(query ben.KRPCMessage, name string) {
+			client, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+
+			defer func() { _ = client.Close() }()
+
+			raw, err := query.MarshalBinary()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = client.WriteTo(raw, net.UDPAddrFromAddrPort(addrOf(conns[1])))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+
+			buff := make([]byte, 1500)
+			n, _, err := client.ReadFrom(buff)
+			Expect(err).NotTo(HaveOccurred())
+
+			resp, err := ben.ParseKRPCMessage(buff[:n])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Err()).To(MatchError(ben.KRPCError{Code: ben.KRPCProtocolError, Message: "missing " + name}))
+		},
+		Entry("get_peers without info_hash", ben.NewGetPeersQuery("xy", ben.NodeID{1}, ben.InfoHash{}), "info_hash"),
+		Entry("find_node without target", ben.NewFindNodeQuery("xy", ben.NodeID{1}, ben.NodeID{}), "target"),
+	)
+
+	It("fails without reachable nodes", func() {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		lonely := ben.NewDHT(conn, ben.RandomNodeID())
+		lonely.Timeout = 100 * time.Millisecond
+
+		go func() { _ = lonely.Serve() }()
+
+		_, err = lonely.FindNode(ctx, ben.RandomNodeID())
+		Expect(err).To(MatchError(ben.ErrNoDHTNodes))
+
+		Expect(lonely.Bootstrap(ctx, addrOf(conn))).To(MatchError(ben.ErrNoDHTNodes))
+
+		_ = conn.Close()
+		_, err = lonely.Ping(ctx, addrOf(conns[0]))
+		Expect(err).To(HaveOccurred())
+	})
+})
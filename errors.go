@@ -14,6 +14,52 @@ var (
 	ErrCannotParseAsList   = InvalidInputError{"cannot parse as list"}
 	ErrCannotParseAsDict   = InvalidInputError{"cannot parse as dictionary"}
 	ErrKeyWithoutValue     = InvalidInputError{"key without value"}
+	ErrInvalidDHTNode      = InvalidInputError{"dht node is not a valid [host, port] pair"}
+	ErrInvalidHashLength   = InvalidInputError{"invalid hash length"}
+	ErrInvalidCompactAddr  = InvalidInputError{"invalid compact address"}
+	ErrInvalidPeerPort     = InvalidInputError{"peer port out of range"}
+
+	ErrPieceOutOfRange    = InvalidInputError{"piece index out of range"}
+	ErrFileOutOfRange     = InvalidInputError{"file index out of range"}
+	ErrInvalidPieceLength = InvalidInputError{"piece length must be a power of two of at least 16 KiB"}
+	ErrEmptyTorrentName   = InvalidInputError{"torrent name is required"}
+	ErrNoFiles            = InvalidInputError{"no files to create the torrent from"}
+	ErrInvalidHashRequest = InvalidInputError{"invalid hash request"}
+	ErrNotUDPTracker      = InvalidInputError{"not a udp tracker url"}
+	ErrTooManyScrapeHash  = InvalidInputError{"too many info-hashes for a single udp scrape"}
+	ErrInvalidUDPResponse = InvalidInputError{"invalid udp tracker response"}
+	ErrInvalidKRPCMessage = InvalidInputError{"invalid krpc message"}
+	ErrInvalidMagnet      = InvalidInputError{"invalid magnet link"}
+	ErrInvalidPeerMessage = InvalidInputError{"invalid peer wire message"}
+
+	ErrInvalidMetadataMessage = InvalidInputError{"invalid ut_metadata message"}
+	ErrInvalidMetadataSize    = InvalidInputError{"invalid metadata size"}
+	ErrInvalidMetadataPiece   = InvalidInputError{"invalid metadata piece"}
+	ErrInvalidResumeData      = InvalidInputError{"invalid resume data"}
+	ErrNoEmbeddedInfo         = InvalidInputError{"resume data has no info dictionary"}
+
+	ErrPaddingFile          = errors.New("padding file has no content")
+	ErrHashMismatch         = errors.New("hashes do not match the merkle root")
+	ErrScrapeNotSupported   = errors.New("announce url does not support scrape convention")
+	ErrUDPTrackerTimeout    = errors.New("udp tracker did not respond")
+	ErrMetadataIncomplete   = errors.New("metadata is incomplete")
+	ErrMetadataHashMismatch = errors.New("metadata does not match the info-hash")
+
+	ErrDHTTimeout   = errors.New("dht query timed out")
+	ErrDHTClosed    = errors.New("dht is closed")
+	ErrNoDHTNodes   = errors.New("no dht node to query")
+	ErrNotAnnounced = errors.New("no dht node accepted the announce")
+	ErrItemNotFound = errors.New("dht item not found")
+	ErrNotStored    = errors.New("no dht node stored the item")
+	ErrNotUpdatable = errors.New("magnet link has no public key")
+
+	// BEP 44 errors, sent back to the storing node as is.
+	ErrItemTooBig           = KRPCError{KRPCMessageTooBig, "message (v field) too big"}
+	ErrInvalidItemSignature = KRPCError{KRPCInvalidSignature, "invalid signature"}
+	ErrSaltTooBig           = KRPCError{KRPCSaltTooBig, "salt (salt field) too big"}
+	ErrCASMismatch          = KRPCError{KRPCCASMismatch, "the CAS hash mismatched, re-read value and try again"}
+	ErrSeqTooLow            = KRPCError{KRPCSeqTooLow, "sequence number less than current"}
+	ErrItemStoreFull        = KRPCError{KRPCServerError, "item store is full"}
 
 	ErrNotAString   = ElementTypeError{"element is not a string"}
 	ErrNotAnInteger = ElementTypeError{"element is not an integer"}
@@ -37,3 +83,22 @@ type ElementTypeError struct {
 func (t ElementTypeError) Error() string {
 	return fmt.Sprintf("Type assertion error: %s", t.string)
 }
+
+// TrackerFailureError carries the `failure reason` sent by a tracker.
+type TrackerFailureError struct {
+	Reason string
+}
+
+func (err TrackerFailureError) Error() string {
+	return fmt.Sprintf("Tracker failure: %s", err.Reason)
+}
+
+// KRPCError is the `[code, message]` list of a DHT error message.
+type KRPCError struct {
+	Code    int64
+	Message string
+}
+
+func (err KRPCError) Error() string {
+	return fmt.Sprintf("KRPC error %d: %s", err.Code, err.Message)
+}
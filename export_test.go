@@ -0,0 +1,11 @@
+package ben
+
+import "time"
+
+// SetPeerClock replaces the clock used to expire the peers announced to d.
+func SetPeerClock(d *DHT, now func() time.Time) {
+	d.peers.mu.Lock()
+	defer d.peers.mu.Unlock()
+
+	d.peers.now = now
+}
@@ -0,0 +1,200 @@
+package ben
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"reflect"
+)
+
+const (
+	// PeerMessageExtended is the peer wire message id of extension messages (BEP 10).
+	PeerMessageExtended = 20
+	// ExtensionHandshakeID is the extended message id of the handshake.
+	ExtensionHandshakeID = 0
+
+	peerMessageLenSize = 4
+)
+
+// ExtensionHandshake is the dictionary of the extension protocol handshake (BEP 10).
+type ExtensionHandshake struct {
+	// M maps the name of every supported extension to its message id,
+	// a zero id disables the extension.
+	M ExtensionIDs `ben:"m,omitempty"`
+	// Port is the local TCP listen port.
+	Port int64 `ben:"p,omitempty"`
+	// Version is the client name and version.
+	Version string `ben:"v,omitempty"`
+	// YourIP is the address of the receiving peer as seen by the sender.
+	YourIP netip.Addr `ben:"yourip,omitempty"`
+	IPv6   netip.Addr `ben:"ipv6,omitempty"`
+	IPv4   netip.Addr `ben:"ipv4,omitempty"`
+	// RequestQueue is the number of outstanding requests the client supports.
+	RequestQueue int64 `ben:"reqq,omitempty"`
+	// MetadataSize is the size of the info dictionary (BEP 9).
+	MetadataSize int64 `ben:"metadata_size,omitempty"`
+	// UploadOnly is set by seeds and partial seeds (BEP 21).
+	UploadOnly bool `ben:"upload_only,omitempty"`
+}
+
+// TryFrom decodes the handshake, malformed optional entries such as a `yourip` of the wrong
+// length are left out instead of failing the whole handshake. Only `m` must be well formed.
+func (ExtensionHandshake) TryFrom(d Dictionary) (ExtensionHandshake, error) {
+	wellFormed := make(map[string]Element, len(d.Val))
+
+	for key, val := range d.Val {
+		if fitsHandshakeEntry(key, val) {
+			wellFormed[key] = val
+		}
+	}
+
+	return castFromDictionaryInto[ExtensionHandshake](Dct(wellFormed))
+}
+
+// fitsHandshakeEntry reports whether val decodes into the field of the optional entry key,
+// other entries are left to the decoder.
+func fitsHandshakeEntry(key string, val Element) bool {
+	switch key {
+	case "p", "reqq", "metadata_size", "upload_only":
+		return val.Type() == IntType
+
+	case "v":
+		return val.Type() == StringType
+
+	case "yourip", "ipv4", "ipv6":
+		compact, err := val.Bytes()
+		_, ok := netip.AddrFromSlice(compact)
+
+		return err == nil && ok
+
+	default:
+		return true
+	}
+}
+
+// TryInto encodes the handshake, `m` is written even when no extension is supported.
+func (h ExtensionHandshake) TryInto() (Dictionary, error) {
+	dict, err := castFromStructIntoDictionary(h)
+	if err != nil {
+		return dict, err
+	}
+
+	if _, ok := dict.Val["m"]; !ok {
+		dict.Val["m"] = Dct(map[string]Element{})
+	}
+
+	return dict, nil
+}
+
+// ExtensionID returns the message id the sender of the handshake assigned to extension,
+// ok is false when the extension is not supported.
+func (h ExtensionHandshake) ExtensionID(extension string) (byte, bool) {
+	id, ok := h.M[extension]
+	if !ok || id <= 0 || id > 255 {
+		return 0, false
+	}
+
+	return byte(id), true
+}
+
+// MarshalBinary encodes the handshake as a complete peer wire message.
+func (h ExtensionHandshake) MarshalBinary() ([]byte, error) {
+	dict, err := h.TryInto()
+	if err != nil {
+		return nil, err
+	}
+
+	return AppendExtendedMessage(nil, ExtensionHandshakeID, dict.Encode()), nil
+}
+
+// ParseExtensionHandshake decodes a handshake from a complete peer wire message.
+func ParseExtensionHandshake(b []byte) (ExtensionHandshake, error) {
+	id, payload, err := ParseExtendedMessage(b)
+	if err != nil {
+		return ExtensionHandshake{}, err
+	}
+
+	if id != ExtensionHandshakeID {
+		return ExtensionHandshake{}, ErrInvalidPeerMessage
+	}
+
+	dict, err := Decode[Dictionary](bufio.NewReader(bytes.NewReader(payload)))
+	if err != nil {
+		return ExtensionHandshake{}, err
+	}
+
+	return ExtensionHandshake{}.TryFrom(dict)
+}
+
+// ExtensionIDs maps extension names to their message ids.
+type ExtensionIDs map[string]int64
+
+func (ExtensionIDs) TryFrom(d Dictionary) (ExtensionIDs, error) {
+	ids := make(ExtensionIDs, len(d.Val))
+
+	for name, elm := range d.Val {
+		id, err := elm.Integer()
+		if err != nil {
+			return ids, err
+		}
+
+		ids[name] = id.Into()
+	}
+
+	return ids, nil
+}
+
+func (m ExtensionIDs) TryInto() (Dictionary, error) {
+	dict := make(map[string]Element, len(m))
+	for name, id := range m {
+		dict[name] = Int(id)
+	}
+
+	return Dct(dict), nil
+}
+
+// AppendExtendedMessage appends payload framed as the extension message extID to dst,
+// that is the length prefix, the PeerMessageExtended id and extID.
+func AppendExtendedMessage(dst []byte, extID byte, payload []byte) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(payload)+2)) //nolint: gosec // bounded by memory
+	dst = append(dst, PeerMessageExtended, extID)
+
+	return append(dst, payload...)
+}
+
+// ParseExtendedMessage strips the framing of a complete extension message,
+// returning its extended message id and its payload.
+func ParseExtendedMessage(b []byte) (byte, []byte, error) {
+	if len(b) < peerMessageLenSize+2 {
+		return 0, nil, ErrInvalidPeerMessage
+	}
+
+	length := binary.BigEndian.Uint32(b)
+	if uint64(length) != uint64(len(b)-peerMessageLenSize) || b[peerMessageLenSize] != PeerMessageExtended {
+		return 0, nil, ErrInvalidPeerMessage
+	}
+
+	return b[peerMessageLenSize+1], b[peerMessageLenSize+2:], nil
+}
+
+func netipAddrStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	compact, err := l.Bytes()
+	if err != nil {
+		return err
+	}
+
+	addr, ok := netip.AddrFromSlice(compact)
+	if !ok {
+		return ErrInvalidPeerMessage
+	}
+
+	obj.Set(reflect.ValueOf(addr))
+
+	return nil
+}
+
+func netipAddrStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	addr, _ := obj.Interface().(netip.Addr)
+	return Str(string(addr.AsSlice())), nil
+}
@@ -0,0 +1,106 @@
+package ben_test
+
+import (
+	"net/netip"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Extension handshake", func() {
+	It("decodes a framed handshake", func() {
+		payload := "d1:md11:ut_metadatai3e6:ut_pexi1e11:lt_donthavei0ee13:metadata_sizei31235e" +
+			"1:pi6881e4:reqqi500e11:upload_onlyi1e1:v14:Transmission 46:yourip4:\x7f\x00\x00\x01e"
+		msg := append([]byte{0, 0, 0, byte(len(payload) + 2), ben.PeerMessageExtended, 0}, payload...)
+
+		handshake, err := ben.ParseExtensionHandshake(msg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(handshake).To(Equal(ben.ExtensionHandshake{
+			M:            ben.ExtensionIDs{"ut_metadata": 3, "ut_pex": 1, "lt_donthave": 0},
+			Port:         6881,
+			Version:      "Transmission 4",
+			YourIP:       netip.MustParseAddr("127.0.0.1"),
+			RequestQueue: 500,
+			MetadataSize: 31235,
+			UploadOnly:   true,
+		}))
+
+		id, ok := handshake.ExtensionID("ut_metadata")
+		Expect(ok).To(BeTrue())
+		Expect(id).To(Equal(byte(3)))
+
+		_, ok = handshake.ExtensionID("lt_donthave")
+		Expect(ok).To(BeFalse())
+
+		_, ok = handshake.ExtensionID("ut_holepunch")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("round-trips through the peer wire framing", func() {
+		handshake := ben.ExtensionHandshake{
+			M:      ben.ExtensionIDs{"ut_metadata": 2},
+			IPv4:   netip.MustParseAddr("192.0.2.1"),
+			IPv6:   netip.MustParseAddr("2001:db8::1"),
+			YourIP: netip.MustParseAddr("2001:db8::2"),
+		}
+
+		msg, err := handshake.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg[4:6]).To(Equal([]byte{ben.PeerMessageExtended, ben.ExtensionHandshakeID}))
+
+		decoded, err := ben.ParseExtensionHandshake(msg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded).To(Equal(handshake))
+	})
+
+	It("skips malformed optional entries", func() {
+		payload := "d1:md6:ut_pexi1ee1:pi6881e4:reqq4:many11:upload_onlyle1:v14:Transmission 46:yourip3:\x7f\x00\x00e"
+		msg := ben.AppendExtendedMessage(nil, ben.ExtensionHandshakeID, []byte(payload))
+
+		handshake, err := ben.ParseExtensionHandshake(msg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(handshake).To(Equal(ben.ExtensionHandshake{
+			M:       ben.ExtensionIDs{"ut_pex": 1},
+			Port:    6881,
+			Version: "Transmission 4",
+		}))
+
+		_, err = ben.ParseExtensionHandshake(ben.AppendExtendedMessage(nil, ben.ExtensionHandshakeID, []byte("d1:mi1ee")))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("always writes the extension dictionary", func() {
+		msg, err := ben.ExtensionHandshake{}.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(msg[6:])).To(Equal("d1:mdee"))
+	})
+
+	It("frames and strips extension messages", func() {
+		msg := ben.AppendExtendedMessage(nil, 3, []byte("d8:msg_typei0e5:piecei0ee"))
+		Expect(msg[:6]).To(Equal([]byte{0, 0, 0, 27, ben.PeerMessageExtended, 3}))
+
+		id, payload, err := ben.ParseExtendedMessage(msg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal(byte(3)))
+		Expect(string(payload)).To(Equal("d8:msg_typei0e5:piecei0ee"))
+	})
+
+	It("rejects malformed framing", func() {
+		for _, msg := range [][]byte{
+			{0, 0, 0, 1, ben.PeerMessageExtended},
+			{0, 0, 0, 3, ben.PeerMessageExtended, 0},
+			{0, 0, 0, 2, 7, 0},
+		} {
+			_, _, err := ben.ParseExtendedMessage(msg)
+			Expect(err).To(MatchError(ben.ErrInvalidPeerMessage))
+		}
+
+		_, err := ben.ParseExtensionHandshake(ben.AppendExtendedMessage(nil, 1, []byte("de")))
+		Expect(err).To(MatchError(ben.ErrInvalidPeerMessage))
+	})
+})
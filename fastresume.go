@@ -0,0 +1,136 @@
+package ben
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" //nolint: gosec // mandated by the spec
+	"crypto/sha256"
+	"time"
+)
+
+// FastResume is a libtorrent `.fastresume` file. Keys not modelled here are kept in Extra
+// and written back as is, along with modelled keys holding a zero value such as
+// `total_downloaded i0e`.
+type FastResume struct {
+	FileFormat        string `ben:"file-format,omitempty"`
+	FileVersion       int64  `ben:"file-version,omitempty"`
+	LibtorrentVersion string `ben:"libtorrent-version,omitempty"`
+
+	InfoHash   InfoHash `ben:"info-hash,omitempty"`
+	InfoHashV2 SHA256   `ben:"info-hash2,omitempty"`
+	Name       string   `ben:"name,omitempty"`
+	SavePath   string   `ben:"save_path,omitempty"`
+
+	Pieces       FastResumePieces `ben:"pieces,omitempty"`
+	FilePriority []int64          `ben:"file_priority,omitempty"`
+
+	Trackers  AnnounceList `ben:"trackers,omitempty"`
+	URLList   []string     `ben:"url-list,omitempty"`
+	HTTPSeeds []string     `ben:"httpseeds,omitempty"`
+
+	AddedTime       time.Time `ben:"added_time,omitempty"`
+	CompletedTime   time.Time `ben:"completed_time,omitempty"`
+	TotalUploaded   int64     `ben:"total_uploaded,omitempty"`
+	TotalDownloaded int64     `ben:"total_downloaded,omitempty"`
+
+	// Info is the info dictionary, kept as is so its info-hash is preserved.
+	Info Element `ben:"info,omitempty"`
+
+	Extra map[string]Element `ben:"-"`
+}
+
+// FastResumePieces holds one byte per piece, the low bit is set for pieces we have.
+type FastResumePieces []byte
+
+// Has reports whether piece is downloaded.
+func (p FastResumePieces) Has(piece int) bool {
+	return piece >= 0 && piece < len(p) && p[piece]&1 != 0
+}
+
+// Bitfield returns the downloaded pieces as a bitfield.
+func (p FastResumePieces) Bitfield() Bitfield {
+	bitfield := NewBitfield(len(p))
+	for piece := range p {
+		if p.Has(piece) {
+			bitfield.Set(piece)
+		}
+	}
+
+	return bitfield
+}
+
+func (FastResume) TryFrom(d Dictionary) (FastResume, error) {
+	resume, err := castFromDictionaryInto[FastResume](d)
+	if err != nil {
+		return resume, err
+	}
+
+	if resume.InfoHash == (InfoHash{}) && resume.InfoHashV2 == (SHA256{}) {
+		return resume, ErrInvalidResumeData
+	}
+
+	resume.Extra, err = leftoverKeys(resume, d)
+
+	return resume, err
+}
+
+// TryInto encodes the resume data, modelled fields take precedence over Extra.
+func (r FastResume) TryInto() (Dictionary, error) {
+	dict, err := castFromStructIntoDictionary(r)
+	if err != nil {
+		return dict, err
+	}
+
+	return withExtra(dict, r.Extra), nil
+}
+
+func ParseFastResume(b []byte) (FastResume, error) {
+	dict, err := Decode[Dictionary](bufio.NewReader(bytes.NewReader(b)))
+	if err != nil {
+		return FastResume{}, err
+	}
+
+	return FastResume{}.TryFrom(dict)
+}
+
+func (r FastResume) MarshalBinary() ([]byte, error) {
+	dict, err := r.TryInto()
+	if err != nil {
+		return nil, err
+	}
+
+	return dict.Encode(), nil
+}
+
+// TorrentInfo decodes the embedded info dictionary, after checking it against the info-hashes.
+func (r FastResume) TorrentInfo() (Info, error) {
+	if r.Info == nil {
+		return Info{}, ErrNoEmbeddedInfo
+	}
+
+	dict, err := r.Info.Dictionary()
+	if err != nil {
+		return Info{}, err
+	}
+
+	if err := r.verifyInfo(dict); err != nil {
+		return Info{}, err
+	}
+
+	return Info{}.TryFrom(dict)
+}
+
+// verifyInfo checks an info dictionary, as found in the file, against the info-hashes.
+func (r FastResume) verifyInfo(info Dictionary) error {
+	encoded := info.Encode()
+
+	if r.InfoHash != (InfoHash{}) && sha1.Sum(encoded) != r.InfoHash { //nolint: gosec // see import
+		return ErrMetadataHashMismatch
+	}
+
+	if r.InfoHashV2 != (SHA256{}) && sha256.Sum256(encoded) != r.InfoHashV2 {
+		return ErrMetadataHashMismatch
+	}
+
+	return nil
+}
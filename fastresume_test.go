@@ -0,0 +1,119 @@
+package ben_test
+
+import (
+	"crypto/sha1" //nolint: gosec // mandated by the spec
+	"time"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("libtorrent fastresume", func() {
+	const info = "d6:lengthi40000e4:name7:abc.iso12:piece lengthi16384e6:pieces60:" +
+		"aaaaaaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbbbbbcccccccccccccccccccce"
+
+	infoHash := sha1.Sum([]byte(info)) //nolint: gosec // see import
+
+	resumeFile := "d10:added_timei1700000000e14:completed_timei1700000600e" +
+		"11:file-format22:libtorrent resume file12:file-versioni1e" +
+		"13:file_priorityli4ee4:info" + info + "9:info-hash20:" + string(infoHash[:]) +
+		"18:libtorrent-version6:2.0.104:name7:abc.iso6:pausedi1e" +
+		"6:pieces3:\x01\x00\x039:save_path10:/downloads14:total_uploadedi123456e" +
+		"8:trackersll17:http://a/announceel12:udp://b:6969ee13:upload_mode_xi0e" +
+		"8:url-listl16:http://c/abc.isoee"
+
+	It("decodes the modelled fields", func() {
+		resume, err := ben.ParseFastResume([]byte(resumeFile))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(resume.FileFormat).To(Equal("libtorrent resume file"))
+		Expect(resume.InfoHash).To(Equal(ben.InfoHash(infoHash)))
+		Expect(resume.SavePath).To(Equal("/downloads"))
+		Expect(resume.FilePriority).To(Equal([]int64{4}))
+		Expect(resume.Trackers).To(Equal(ben.AnnounceList{{"http://a/announce"}, {"udp://b:6969"}}))
+		Expect(resume.URLList).To(Equal([]string{"http://c/abc.iso"}))
+		Expect(resume.AddedTime).To(Equal(time.Unix(1700000000, 0)))
+		Expect(resume.CompletedTime).To(Equal(time.Unix(1700000600, 0)))
+		Expect(resume.TotalUploaded).To(Equal(int64(123456)))
+
+		Expect(resume.Pieces.Has(0)).To(BeTrue())
+		Expect(resume.Pieces.Has(1)).To(BeFalse())
+		Expect(resume.Pieces.Has(2)).To(BeTrue())
+		Expect(resume.Pieces.Bitfield()).To(Equal(ben.Bitfield{0xa0}))
+		Expect(resume.Pieces.Bitfield().Count()).To(Equal(2))
+
+		Expect(resume.Extra).To(Equal(map[string]ben.Element{
+			"paused":        ben.Int(1),
+			"upload_mode_x": ben.Int(0),
+		}))
+	})
+
+	It("writes back the same file", func() {
+		resume, err := ben.ParseFastResume([]byte(resumeFile))
+		Expect(err).NotTo(HaveOccurred())
+
+		raw, err := resume.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(raw)).To(Equal(resumeFile))
+	})
+
+	It("writes changes along with the unknown keys", func() {
+		resume, err := ben.ParseFastResume([]byte(resumeFile))
+		Expect(err).NotTo(HaveOccurred())
+
+		resume.SavePath = "/mnt/data"
+		resume.Extra["paused"] = ben.Int(0)
+
+		raw, err := resume.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+
+		updated, err := ben.ParseFastResume(raw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.SavePath).To(Equal("/mnt/data"))
+		Expect(updated.Extra).To(HaveKeyWithValue("paused", ben.Int(0)))
+	})
+
+	It("writes back zero counters", func() {
+		source := "d9:info-hash20:" + string(infoHash[:]) + "16:total_downloadedi0e14:total_uploadedi0ee"
+
+		resume, err := ben.ParseFastResume([]byte(source))
+		Expect(err).NotTo(HaveOccurred())
+
+		raw, err := resume.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(raw)).To(Equal(source))
+
+		resume.TotalUploaded = 42
+		raw, err = resume.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(raw)).To(HaveSuffix("16:total_downloadedi0e14:total_uploadedi42ee"))
+	})
+
+	It("decodes the embedded info dictionary", func() {
+		resume, err := ben.ParseFastResume([]byte(resumeFile))
+		Expect(err).NotTo(HaveOccurred())
+
+		torrentInfo, err := resume.TorrentInfo()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(torrentInfo.Name).To(Equal("abc.iso"))
+		Expect(torrentInfo.Pieces).To(HaveLen(3))
+
+		resume.InfoHash = ben.InfoHash{1}
+		_, err = resume.TorrentInfo()
+		Expect(err).To(MatchError(ben.ErrMetadataHashMismatch))
+
+		resume.Info = nil
+		_, err = resume.TorrentInfo()
+		Expect(err).To(MatchError(ben.ErrNoEmbeddedInfo))
+	})
+
+	It("requires an info-hash", func() {
+		_, err := ben.ParseFastResume([]byte("d9:save_path1:/e"))
+		Expect(err).To(MatchError(ben.ErrInvalidResumeData))
+	})
+})
@@ -0,0 +1,208 @@
+package ben
+
+import (
+	"crypto/sha256"
+	"maps"
+	"slices"
+)
+
+// SHA256 is a hash used by BitTorrent v2 (BEP 52), e.g. a `pieces root`.
+type SHA256 [32]byte
+
+// FileTree is the `file tree` of a v2 info dictionary.
+// A node is either a directory holding Entries, or a file.
+type FileTree struct {
+	File    *FileTreeFile
+	Entries map[string]FileTree
+}
+
+// FileTreeFile is stored under the empty-string key of a file node.
+type FileTreeFile struct {
+	Length int64 `ben:"length"`
+	// PiecesRoot is absent for empty files.
+	PiecesRoot SHA256 `ben:"pieces root,omitempty"`
+	Attr       string `ben:"attr,omitempty"`
+
+	// Extra holds the entries not written back from the fields above, see Info.
+	Extra map[string]Element `ben:"-"`
+}
+
+func (FileTreeFile) TryFrom(d Dictionary) (FileTreeFile, error) {
+	file, err := castFromDictionaryInto[FileTreeFile](d)
+	if err != nil {
+		return file, err
+	}
+
+	file.Extra, err = leftoverKeys(file, d)
+
+	return file, err
+}
+
+func (f FileTreeFile) TryInto() (Dictionary, error) {
+	dict, err := castFromStructIntoDictionary(f)
+	return withExtra(dict, f.Extra), err
+}
+
+func (FileTree) TryFrom(d Dictionary) (FileTree, error) {
+	var tree FileTree
+
+	for name, elm := range d.Val {
+		dict, err := elm.Dictionary()
+		if err != nil {
+			return tree, err
+		}
+
+		if name == "" {
+			file, err := FileTreeFile{}.TryFrom(dict)
+			if err != nil {
+				return tree, err
+			}
+
+			tree.File = &file
+
+			continue
+		}
+
+		node, err := FileTree{}.TryFrom(dict)
+		if err != nil {
+			return tree, err
+		}
+
+		if tree.Entries == nil {
+			tree.Entries = make(map[string]FileTree)
+		}
+
+		tree.Entries[name] = node
+	}
+
+	return tree, nil
+}
+
+func (t FileTree) TryInto() (Dictionary, error) {
+	dict := make(map[string]Element)
+
+	if t.File != nil {
+		file, err := t.File.TryInto()
+		if err != nil {
+			return Dct(dict), err
+		}
+
+		dict[""] = file
+	}
+
+	for name, node := range t.Entries {
+		nodeDict, err := node.TryInto()
+		if err != nil {
+			return Dct(dict), err
+		}
+
+		dict[name] = nodeDict
+	}
+
+	return Dct(dict), nil
+}
+
+// V2File is a file listed from a FileTree.
+type V2File struct {
+	Path []string
+	FileTreeFile
+}
+
+// Files lists every file in the tree, ordered by path.
+func (t FileTree) Files() []V2File {
+	var files []V2File
+
+	t.walk(nil, func(path []string, file FileTreeFile) {
+		files = append(files, V2File{Path: path, FileTreeFile: file})
+	})
+
+	return files
+}
+
+func (t FileTree) walk(path []string, fn func([]string, FileTreeFile)) {
+	if t.File != nil {
+		fn(slices.Clone(path), *t.File)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(t.Entries)) {
+		t.Entries[name].walk(append(path, name), fn)
+	}
+}
+
+// PieceLayers maps a `pieces root` to the hashes of its piece layer.
+type PieceLayers map[SHA256][]SHA256
+
+func (PieceLayers) TryFrom(d Dictionary) (PieceLayers, error) {
+	layers := make(PieceLayers, len(d.Val))
+
+	for root, elm := range d.Val {
+		if len(root) != sha256.Size {
+			return layers, ErrInvalidHashLength
+		}
+
+		str, err := elm.String()
+		if err != nil {
+			return layers, err
+		}
+
+		hashes, err := splitSHA256(str.Into())
+		if err != nil {
+			return layers, err
+		}
+
+		layers[SHA256([]byte(root))] = hashes
+	}
+
+	return layers, nil
+}
+
+func (p PieceLayers) TryInto() (Dictionary, error) {
+	dict := make(map[string]Element, len(p))
+
+	for root, hashes := range p {
+		dict[string(root[:])] = Str(joinSHA256(hashes))
+	}
+
+	return Dct(dict), nil
+}
+
+func splitSHA256(s string) ([]SHA256, error) {
+	if len(s)%sha256.Size != 0 {
+		return nil, ErrInvalidHashLength
+	}
+
+	hashes := make([]SHA256, 0, len(s)/sha256.Size)
+	for start := 0; start < len(s); start += sha256.Size {
+		hashes = append(hashes, SHA256([]byte(s[start:start+sha256.Size])))
+	}
+
+	return hashes, nil
+}
+
+func joinSHA256(hashes []SHA256) string {
+	buff := make([]byte, 0, len(hashes)*sha256.Size)
+	for _, hash := range hashes {
+		buff = append(buff, hash[:]...)
+	}
+
+	return string(buff)
+}
+
+func (i Info) IsV2() bool {
+	return i.MetaVersion == 2 //nolint: mnd // meta version from BEP 52
+}
+
+// IsHybrid reports whether the torrent carries both v1 and v2 metadata.
+func (i Info) IsHybrid() bool {
+	return i.IsV2() && i.Pieces != nil
+}
+
+// HashV2 returns the v2 info-hash, which is the SHA-256 of the bencoded info dictionary.
+func (i Info) HashV2() (SHA256, error) {
+	dict, err := i.TryInto()
+	if err != nil {
+		return SHA256{}, err
+	}
+
+	return sha256.Sum256(dict.Encode()), nil
+}
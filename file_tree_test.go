@@ -0,0 +1,86 @@
+package ben_test
+
+import (
+	"bufio"
+	"bytes"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BitTorrent v2 metainfo", func() {
+	var (
+		rootA = ben.SHA256(bytes.Repeat([]byte{0xaa}, 32))
+		rootB = ben.SHA256(bytes.Repeat([]byte{0xbb}, 32))
+		layer = []ben.SHA256{
+			ben.SHA256(bytes.Repeat([]byte{0x01}, 32)),
+			ben.SHA256(bytes.Repeat([]byte{0x02}, 32)),
+		}
+
+		torrent ben.Torrent
+	)
+
+	BeforeEach(func() {
+		torrent = ben.Torrent{
+			Announce: "http://a/announce",
+			Info: ben.Info{
+				Name:        "abc",
+				PieceLength: 16384,
+				MetaVersion: 2,
+				FileTree: ben.FileTree{Entries: map[string]ben.FileTree{
+					"a.txt": {File: &ben.FileTreeFile{Length: 6, PiecesRoot: rootA}},
+					"dir": {Entries: map[string]ben.FileTree{
+						"b.bin": {File: &ben.FileTreeFile{Length: 32768, PiecesRoot: rootB}},
+					}},
+					"empty": {File: &ben.FileTreeFile{Length: 0}},
+				}},
+			},
+			PieceLayers: ben.PieceLayers{rootB: layer},
+		}
+	})
+
+	It("lists files from the tree ordered by path", func() {
+		files := torrent.Info.FileTree.Files()
+		Expect(files).To(HaveLen(3))
+		Expect(files[0].Path).To(Equal([]string{"a.txt"}))
+		Expect(files[0].PiecesRoot).To(Equal(rootA))
+		Expect(files[1].Path).To(Equal([]string{"dir", "b.bin"}))
+		Expect(files[1].Length).To(Equal(int64(32768)))
+		Expect(files[2].Path).To(Equal([]string{"empty"}))
+		Expect(files[2].PiecesRoot).To(BeZero())
+	})
+
+	It("encodes file nodes under the empty-string key", func() {
+		dict, err := torrent.Info.FileTree.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(HavePrefix("d5:a.txtd0:d6:lengthi6e11:pieces root32:"))
+		Expect(string(dict.Encode())).To(HaveSuffix("5:emptyd0:d6:lengthi0eeee"))
+	})
+
+	It("survives a decode/encode round trip", func() {
+		dict, err := torrent.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+
+		decoded, err := decodeTorrent(string(dict.Encode()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded).To(Equal(torrent))
+		Expect(decoded.Info.IsV2()).To(BeTrue())
+		Expect(decoded.Info.IsHybrid()).To(BeFalse())
+		Expect(decoded.PieceLayers[rootB]).To(Equal(layer))
+	})
+
+	It("rejects piece layers with a truncated hash", func() {
+		source := "d32:" + string(rootB[:]) + "31:" + string(bytes.Repeat([]byte{1}, 31)) + "e"
+
+		dict, err := ben.Decode[ben.Dictionary](bufio.NewReader(bytes.NewBufferString(source)))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = ben.PieceLayers{}.TryFrom(dict)
+		Expect(err).To(MatchError(ben.ErrInvalidHashLength))
+	})
+})
@@ -0,0 +1,232 @@
+package ben
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint: gosec // sha1 is mandated by the spec
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// File attributes from BEP 47.
+const (
+	AttrPadding    = 'p'
+	AttrExecutable = 'x'
+	AttrHidden     = 'h'
+	AttrSymlink    = 'l'
+)
+
+func (f File) IsPadding() bool {
+	return strings.ContainsRune(f.Attr, AttrPadding)
+}
+
+func (f File) IsExecutable() bool {
+	return strings.ContainsRune(f.Attr, AttrExecutable)
+}
+
+func (f File) IsHidden() bool {
+	return strings.ContainsRune(f.Attr, AttrHidden)
+}
+
+func (f File) IsSymlink() bool {
+	return strings.ContainsRune(f.Attr, AttrSymlink)
+}
+
+// FileSpan is the part of a single file covered by a range of torrent content.
+type FileSpan struct {
+	// FileIndex is the index into Info.Files, always 0 for single-file torrents.
+	FileIndex int
+	// Path includes the torrent name as its first component.
+	Path   []string
+	Offset int64
+	Length int64
+	// Padding spans are all zeroes and do not exist on disk.
+	Padding bool
+}
+
+func (i Info) IsMultiFile() bool {
+	return len(i.Files) > 0
+}
+
+// files returns Files, or the torrent itself as the only file for single-file torrents.
+func (i Info) files() []File {
+	if i.IsMultiFile() {
+		return i.Files
+	}
+
+	return []File{{Length: i.Length, Attr: i.Attr, SymlinkPath: i.SymlinkPath, SHA1: i.SHA1}}
+}
+
+// TotalLength returns the length of the torrent content, padding files included.
+func (i Info) TotalLength() int64 {
+	var total int64
+	for _, file := range i.files() {
+		total += file.Length
+	}
+
+	return total
+}
+
+// ContentLength returns the length of the torrent content, padding files excluded.
+func (i Info) ContentLength() int64 {
+	var total int64
+	for _, file := range i.files() {
+		if !file.IsPadding() {
+			total += file.Length
+		}
+	}
+
+	return total
+}
+
+// FileList returns a span covering each file of the torrent, padding files excluded.
+func (i Info) FileList() []FileSpan {
+	var spans []FileSpan
+
+	for idx, file := range i.files() {
+		if file.IsPadding() {
+			continue
+		}
+
+		spans = append(spans, FileSpan{
+			FileIndex: idx,
+			Path:      append([]string{i.Name}, file.Path...),
+			Length:    file.Length,
+		})
+	}
+
+	return spans
+}
+
+func (i Info) PieceCount() int {
+	return len(i.Pieces)
+}
+
+// PieceSize returns the length of the given piece,
+// only the last piece may be shorter than PieceLength.
+func (i Info) PieceSize(index int) (int64, error) {
+	if index < 0 || index >= i.PieceCount() {
+		return 0, fmt.Errorf("%w: %d", ErrPieceOutOfRange, index)
+	}
+
+	if index < i.PieceCount()-1 {
+		return i.PieceLength, nil
+	}
+
+	return i.TotalLength() - int64(index)*i.PieceLength, nil
+}
+
+// FileSpans maps length bytes of torrent content starting at offset into
+// the files they are stored in.
+func (i Info) FileSpans(offset, length int64) []FileSpan {
+	var (
+		spans []FileSpan
+		start int64
+	)
+
+	end := offset + length
+
+	for idx, file := range i.files() {
+		fileEnd := start + file.Length
+
+		if fileEnd > offset && start < end {
+			spanStart := max(offset, start)
+			spanEnd := min(end, fileEnd)
+
+			spans = append(spans, FileSpan{
+				FileIndex: idx,
+				Path:      append([]string{i.Name}, file.Path...),
+				Offset:    spanStart - start,
+				Length:    spanEnd - spanStart,
+				Padding:   file.IsPadding(),
+			})
+		}
+
+		start = fileEnd
+		if start >= end {
+			break
+		}
+	}
+
+	return spans
+}
+
+// PieceSpans maps a piece into the files it is stored in.
+func (i Info) PieceSpans(index int) ([]FileSpan, error) {
+	size, err := i.PieceSize(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.FileSpans(int64(index)*i.PieceLength, size), nil
+}
+
+// ReadPiece reads a piece from fsys, which is rooted at the directory the torrent is saved to.
+// Padding files are not read but filled with zeroes.
+func (i Info) ReadPiece(fsys fs.FS, index int) ([]byte, error) {
+	spans, err := i.PieceSpans(index)
+	if err != nil {
+		return nil, err
+	}
+
+	var piece bytes.Buffer
+
+	for _, span := range spans {
+		if span.Padding {
+			piece.Write(make([]byte, span.Length))
+			continue
+		}
+
+		if err = readSpan(fsys, span, &piece); err != nil {
+			return nil, err
+		}
+	}
+
+	return piece.Bytes(), nil
+}
+
+func readSpan(fsys fs.FS, span FileSpan, w io.Writer) error {
+	file, err := fsys.Open(path.Join(span.Path...))
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	src := io.Reader(file)
+	if readerAt, ok := file.(io.ReaderAt); ok {
+		src = io.NewSectionReader(readerAt, span.Offset, span.Length)
+	} else {
+		_, err = io.CopyN(io.Discard, file, span.Offset)
+	}
+
+	if err == nil {
+		_, err = io.CopyN(w, src, span.Length)
+	}
+
+	// a file shorter than listed in the torrent
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// VerifyPiece checks data against the SHA-1 hash of the given piece.
+func (i Info) VerifyPiece(index int, data []byte) (bool, error) {
+	size, err := i.PieceSize(index)
+	if err != nil {
+		return false, err
+	}
+
+	if int64(len(data)) != size {
+		return false, nil
+	}
+
+	sum := sha1.Sum(data) //nolint: gosec // sha1 is mandated by the spec
+
+	return bytes.Equal(sum[:], i.Pieces[index]), nil
+}
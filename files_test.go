@@ -0,0 +1,97 @@
+package ben_test
+
+import (
+	"crypto/sha1"
+	"io"
+	"testing/fstest"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Padding files", func() {
+	var (
+		info ben.Info
+		fsys fstest.MapFS
+	)
+
+	BeforeEach(func() {
+		fsys = fstest.MapFS{
+			"abc/a.txt":     {Data: []byte("aaaaaa")},
+			"abc/bin/b.exe": {Data: []byte("bbbbbbbb")},
+		}
+
+		first := sha1.Sum([]byte("aaaaaa\x00\x00"))
+		second := sha1.Sum([]byte("bbbbbbbb"))
+
+		info = ben.Info{
+			Name:        "abc",
+			PieceLength: 8,
+			Pieces:      []ben.SHA1{first[:], second[:]},
+			Files: []ben.File{
+				{Length: 6, Path: []string{"a.txt"}},
+				{Length: 2, Path: []string{".pad", "2"}, Attr: "p"},
+				{Length: 8, Path: []string{"bin", "b.exe"}, Attr: "x", SHA1: second[:]},
+			},
+		}
+	})
+
+	It("reports file attributes", func() {
+		Expect(info.Files[1].IsPadding()).To(BeTrue())
+		Expect(info.Files[2].IsExecutable()).To(BeTrue())
+		Expect(info.Files[2].IsHidden()).To(BeFalse())
+		Expect(info.Files[2].IsSymlink()).To(BeFalse())
+	})
+
+	It("leaves padding files out of the file list", func() {
+		files := info.FileList()
+		Expect(files).To(HaveLen(2))
+		Expect(files[0].Path).To(Equal([]string{"abc", "a.txt"}))
+		Expect(files[1].Path).To(Equal([]string{"abc", "bin", "b.exe"}))
+		Expect(files[1].FileIndex).To(Equal(2))
+		Expect(info.ContentLength()).To(Equal(int64(14)))
+		Expect(info.TotalLength()).To(Equal(int64(16)))
+	})
+
+	It("does not request padding from web seeds", func() {
+		reqs, err := info.PieceWebSeedRequests("http://a/", 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reqs).To(HaveLen(1))
+		Expect(reqs[0].URL).To(Equal("http://a/abc/a.txt"))
+
+		_, err = info.FileWebSeedRequest("http://a/", 1)
+		Expect(err).To(MatchError(ben.ErrPaddingFile))
+	})
+
+	It("verifies pieces with padding filled with zeroes", func() {
+		for idx := range info.PieceCount() {
+			piece, err := info.ReadPiece(fsys, idx)
+			Expect(err).NotTo(HaveOccurred())
+
+			ok, err := info.VerifyPiece(idx, piece)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+		}
+	})
+
+	It("fails on files shorter than listed", func() {
+		fsys["abc/a.txt"] = &fstest.MapFile{Data: []byte("aaa")}
+
+		_, err := info.ReadPiece(fsys, 0)
+		Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+	})
+
+	It("keeps attributes through a round trip", func() {
+		dict, err := info.Files[2].TryInto()
+		Expect(err).NotTo(HaveOccurred())
+
+		file, err := ben.File{}.TryFrom(dict)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file).To(Equal(info.Files[2]))
+	})
+})
@@ -0,0 +1,24 @@
+package ben
+
+import (
+	"crypto/sha1" //nolint: gosec // sha1 is mandated by the spec
+	"encoding/hex"
+)
+
+type InfoHash [20]byte
+
+func (h InfoHash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// Hash returns the v1 info-hash, which is the SHA-1 of the bencoded info dictionary.
+// Entries not modelled in Info are kept in Extra, so the hash of a decoded Info matches
+// the original one.
+func (i Info) Hash() (InfoHash, error) {
+	dict, err := i.TryInto()
+	if err != nil {
+		return InfoHash{}, err
+	}
+
+	return sha1.Sum(dict.Encode()), nil //nolint: gosec // see above
+}
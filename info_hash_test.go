@@ -0,0 +1,89 @@
+package ben_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" //nolint: gosec // mandated by the spec
+	"crypto/sha256"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+func decodeInfo(source string) ben.Info {
+	dict, err := ben.Decode[ben.Dictionary](bufio.NewReader(bytes.NewBufferString(source)))
+	Expect(err).NotTo(HaveOccurred())
+
+	info, err := ben.Info{}.TryFrom(dict)
+	Expect(err).NotTo(HaveOccurred())
+
+	return info
+}
+
+var _ = Describe("Info-hash", func() {
+	It("keeps unmodelled and empty entries of v1 info dictionaries", func() {
+		source := "d5:filesld6:lengthi6e6:md5sum32:0123456789abcdef0123456789abcdef4:pathl5:a.txteee" +
+			"4:name3:abc10:name.utf-83:abc12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaa" +
+			"7:privatei0e9:publisher3:fooe"
+
+		info := decodeInfo(source)
+		Expect(info.Extra).To(Equal(map[string]ben.Element{
+			"name.utf-8": ben.Str("abc"),
+			"private":    ben.Int(0),
+			"publisher":  ben.Str("foo"),
+		}))
+		Expect(info.Files[0].Extra).To(HaveKeyWithValue("md5sum", ben.Str("0123456789abcdef0123456789abcdef")))
+
+		dict, err := info.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(Equal(source))
+
+		hash, err := info.Hash()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hash).To(Equal(ben.InfoHash(sha1.Sum([]byte(source))))) //nolint: gosec // see import
+	})
+
+	It("keeps unmodelled entries of v2 file trees", func() {
+		source := "d9:file treed5:a.txtd0:d6:lengthi6e5:mtimei1700000000e11:pieces root32:" +
+			"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaeee12:meta versioni2e4:name3:abc12:piece lengthi16384e" +
+			"8:x-sourcei1ee"
+
+		info := decodeInfo(source)
+		Expect(info.FileTree.Entries["a.txt"].File.Extra).To(HaveKeyWithValue("mtime", ben.Int(1700000000)))
+
+		hash, err := info.HashV2()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hash).To(Equal(ben.SHA256(sha256.Sum256([]byte(source)))))
+	})
+
+	It("lets modelled fields take precedence", func() {
+		info := decodeInfo("d4:name3:abc6:pieces0:7:privatei0ee")
+		info.Private = true
+
+		dict, err := info.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dict.Val).To(HaveKeyWithValue("private", ben.Int(1)))
+	})
+
+	It("keeps a private flag other than 0 or 1", func() {
+		source := "d4:name3:abc6:pieces0:7:privatei2ee"
+
+		info := decodeInfo(source)
+		Expect(info.Private).To(BeTrue())
+
+		dict, err := info.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(Equal(source))
+
+		info.Private = false
+
+		dict, err = info.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dict.Val).NotTo(HaveKey("private"))
+	})
+})
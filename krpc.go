@@ -0,0 +1,478 @@
+package ben
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"net/netip"
+	"reflect"
+)
+
+// KRPC message types, the `y` key.
+const (
+	KRPCTypeQuery    = "q"
+	KRPCTypeResponse = "r"
+	KRPCTypeError    = "e"
+)
+
+// Queries from BEP 5, the `q` key.
+const (
+	QueryPing         = "ping"
+	QueryFindNode     = "find_node"
+	QueryGetPeers     = "get_peers"
+	QueryAnnouncePeer = "announce_peer"
+
+	// infohash indexing from BEP 51
+	QuerySampleInfoHashes = "sample_infohashes"
+
+	// storage queries from BEP 44
+	QueryGet = "get"
+	QueryPut = "put"
+)
+
+// Error codes from BEP 5.
+const (
+	KRPCGenericError  = 201
+	KRPCServerError   = 202
+	KRPCProtocolError = 203
+	KRPCMethodUnknown = 204
+
+	// BEP 44
+	KRPCMessageTooBig    = 205
+	KRPCInvalidSignature = 206
+	KRPCSaltTooBig       = 207
+	KRPCCASMismatch      = 301
+	KRPCSeqTooLow        = 302
+)
+
+const (
+	compactNodeLen  = 26
+	compactNode6Len = 38
+)
+
+type NodeID [20]byte
+
+func (id NodeID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// NodeInfo is a DHT node as found in `nodes` and `nodes6`.
+type NodeInfo struct {
+	ID   NodeID
+	Addr netip.AddrPort
+}
+
+// CompactNodes is a compact string of 26-byte node entries.
+type CompactNodes []NodeInfo
+
+// CompactNodes6 is a compact string of 38-byte node entries (BEP 32).
+type CompactNodes6 []NodeInfo
+
+// PeerValues is the `values` list of a get_peers response, each peer is a compact string.
+type PeerValues []netip.AddrPort
+
+// KRPCMessage is a single DHT message. Queries carry Args, responses carry Response,
+// and errors carry Error.
+type KRPCMessage struct {
+	TransactionID string      `ben:"t"`
+	Type          string      `ben:"y"`
+	Query         string      `ben:"q,omitempty"`
+	Args          *KRPCArgs   `ben:"a,omitempty"`
+	Response      *KRPCReturn `ben:"r,omitempty"`
+	Error         *KRPCError  `ben:"e,omitempty"`
+	Version       string      `ben:"v,omitempty"`
+	// IP is the address of the receiver as seen by the sender (BEP 42).
+	IP netip.AddrPort `ben:"ip,omitempty"`
+	// ReadOnly is set by nodes not answering queries (BEP 43).
+	ReadOnly bool `ben:"ro,omitempty"`
+}
+
+// KRPCArgs holds the arguments of every query, only ID is always present.
+type KRPCArgs struct {
+	ID          NodeID   `ben:"id"`
+	Target      NodeID   `ben:"target,omitempty"`
+	InfoHash    InfoHash `ben:"info_hash,omitempty"`
+	Port        int64    `ben:"port,omitempty"`
+	ImpliedPort bool     `ben:"implied_port,omitempty"`
+	Token       string   `ben:"token,omitempty"`
+	// Want lists the requested address families, `n4` and `n6` (BEP 32).
+	Want []string `ben:"want,omitempty"`
+
+	// BEP 33 scrapes on get_peers, and seed status on announce_peer.
+	Scrape bool `ben:"scrape,omitempty"`
+	NoSeed bool `ben:"noseed,omitempty"`
+	Seed   bool `ben:"seed,omitempty"`
+
+	// BEP 44 get and put, Key is only set for mutable items.
+	Value     Element   `ben:"v,omitempty"`
+	Key       PublicKey `ben:"k,omitempty"`
+	Salt      string    `ben:"salt,omitempty"`
+	Seq       *int64    `ben:"seq,omitempty"`
+	CAS       *int64    `ben:"cas,omitempty"`
+	Signature Signature `ben:"sig,omitempty"`
+}
+
+// KRPCReturn holds the return values of every response, only ID is always present.
+type KRPCReturn struct {
+	ID     NodeID        `ben:"id"`
+	Nodes  CompactNodes  `ben:"nodes,omitempty"`
+	Nodes6 CompactNodes6 `ben:"nodes6,omitempty"`
+	Token  string        `ben:"token,omitempty"`
+	Values PeerValues    `ben:"values,omitempty"`
+
+	// BEP 33 bloom filters of the seeds and the peers of a scraped torrent.
+	SeedFilter *BloomFilter `ben:"BFsd,omitempty"`
+	PeerFilter *BloomFilter `ben:"BFpe,omitempty"`
+
+	// BEP 51 sample_infohashes, Interval is in seconds. Num and Samples are
+	// pointers as both are required even when no info-hash is stored.
+	Interval int64       `ben:"interval,omitempty"`
+	Num      *int64      `ben:"num,omitempty"`
+	Samples  *InfoHashes `ben:"samples,omitempty"`
+
+	// BEP 44 get, Key is only set for mutable items.
+	Value     Element   `ben:"v,omitempty"`
+	Key       PublicKey `ben:"k,omitempty"`
+	Seq       *int64    `ben:"seq,omitempty"`
+	Signature Signature `ben:"sig,omitempty"`
+}
+
+func (KRPCArgs) TryFrom(d Dictionary) (KRPCArgs, error) {
+	return castFromDictionaryInto[KRPCArgs](d)
+}
+
+func (a KRPCArgs) TryInto() (Dictionary, error) {
+	return castFromStructIntoDictionary(a)
+}
+
+func (KRPCReturn) TryFrom(d Dictionary) (KRPCReturn, error) {
+	return castFromDictionaryInto[KRPCReturn](d)
+}
+
+func (r KRPCReturn) TryInto() (Dictionary, error) {
+	return castFromStructIntoDictionary(r)
+}
+
+func (KRPCMessage) TryFrom(d Dictionary) (KRPCMessage, error) {
+	msg, err := castFromDictionaryInto[KRPCMessage](d)
+	if err != nil {
+		return msg, err
+	}
+
+	return msg, msg.validate()
+}
+
+func (m KRPCMessage) TryInto() (Dictionary, error) {
+	if err := m.validate(); err != nil {
+		return Dictionary{}, err
+	}
+
+	return castFromStructIntoDictionary(m)
+}
+
+func (m KRPCMessage) validate() error {
+	switch m.Type {
+	case KRPCTypeQuery:
+		if m.Query == "" || m.Args == nil {
+			return ErrInvalidKRPCMessage
+		}
+
+	case KRPCTypeResponse:
+		if m.Response == nil {
+			return ErrInvalidKRPCMessage
+		}
+
+	case KRPCTypeError:
+		if m.Error == nil {
+			return ErrInvalidKRPCMessage
+		}
+
+	default:
+		return ErrInvalidKRPCMessage
+	}
+
+	return nil
+}
+
+// ParseKRPCMessage decodes a single KRPC message, usually the payload of a UDP packet.
+func ParseKRPCMessage(b []byte) (KRPCMessage, error) {
+	dict, err := Decode[Dictionary](bufio.NewReader(bytes.NewReader(b)))
+	if err != nil {
+		return KRPCMessage{}, err
+	}
+
+	return KRPCMessage{}.TryFrom(dict)
+}
+
+// MarshalBinary encodes the message, keys are written in sorted order.
+func (m KRPCMessage) MarshalBinary() ([]byte, error) {
+	dict, err := m.TryInto()
+	if err != nil {
+		return nil, err
+	}
+
+	return dict.Encode(), nil
+}
+
+// Err returns the error carried by an error message, if any.
+func (m KRPCMessage) Err() error {
+	if m.Type != KRPCTypeError || m.Error == nil {
+		return nil
+	}
+
+	return *m.Error
+}
+
+// SenderID returns the node id of the sender of a query or a response.
+func (m KRPCMessage) SenderID() (NodeID, bool) {
+	switch {
+	case m.Args != nil:
+		return m.Args.ID, true
+	case m.Response != nil:
+		return m.Response.ID, true
+	default:
+		return NodeID{}, false
+	}
+}
+
+func NewPingQuery(tid string, id NodeID) KRPCMessage {
+	return newQuery(tid, QueryPing, KRPCArgs{ID: id})
+}
+
+func NewFindNodeQuery(tid string, id, target NodeID) KRPCMessage {
+	return newQuery(tid, QueryFindNode, KRPCArgs{ID: id, Target: target})
+}
+
+func NewGetPeersQuery(tid string, id NodeID, infoHash InfoHash) KRPCMessage {
+	return newQuery(tid, QueryGetPeers, KRPCArgs{ID: id, InfoHash: infoHash})
+}
+
+// NewAnnouncePeerQuery announces port for infoHash with the token of a previous get_peers,
+// a zero port sets `implied_port` so the receiver uses the source port of the packet.
+func NewAnnouncePeerQuery(tid string, id NodeID, infoHash InfoHash, port uint16, token string) KRPCMessage {
+	return newQuery(tid, QueryAnnouncePeer, KRPCArgs{
+		ID:          id,
+		InfoHash:    infoHash,
+		Port:        int64(port),
+		ImpliedPort: port == 0,
+		Token:       token,
+	})
+}
+
+func newQuery(tid, query string, args KRPCArgs) KRPCMessage {
+	return KRPCMessage{TransactionID: tid, Type: KRPCTypeQuery, Query: query, Args: &args}
+}
+
+// NewScrapeQuery is a get_peers query asking for the bloom filters of the swarm (BEP 33),
+// seeds are left out of the returned peers when noSeed is set.
+func NewScrapeQuery(tid string, id NodeID, infoHash InfoHash, noSeed bool) KRPCMessage {
+	return newQuery(tid, QueryGetPeers, KRPCArgs{ID: id, InfoHash: infoHash, Scrape: true, NoSeed: noSeed})
+}
+
+func NewSampleInfoHashesQuery(tid string, id, target NodeID) KRPCMessage {
+	return newQuery(tid, QuerySampleInfoHashes, KRPCArgs{ID: id, Target: target})
+}
+
+func NewKRPCResponse(tid string, ret KRPCReturn) KRPCMessage {
+	return KRPCMessage{TransactionID: tid, Type: KRPCTypeResponse, Response: &ret}
+}
+
+func NewKRPCErrorMessage(tid string, code int64, message string) KRPCMessage {
+	return KRPCMessage{
+		TransactionID: tid,
+		Type:          KRPCTypeError,
+		Error:         &KRPCError{Code: code, Message: message},
+	}
+}
+
+// ParseCompactNodes parses a string of concatenated compact node entries, each size bytes long.
+// size is either 26 for `nodes` or 38 for `nodes6`.
+func ParseCompactNodes(b []byte, size int) ([]NodeInfo, error) {
+	if size != compactNodeLen && size != compactNode6Len || len(b)%size != 0 {
+		return nil, ErrInvalidCompactAddr
+	}
+
+	idLen := len(NodeID{})
+	nodes := make([]NodeInfo, 0, len(b)/size)
+
+	for start := 0; start < len(b); start += size {
+		addr, err := ParseCompactAddrPort(b[start+idLen : start+size])
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, NodeInfo{ID: NodeID(b[start : start+idLen]), Addr: addr})
+	}
+
+	return nodes, nil
+}
+
+// AppendCompactNode appends the compact form of node to b.
+func AppendCompactNode(b []byte, node NodeInfo) []byte {
+	return AppendCompactAddrPort(append(b, node.ID[:]...), node.Addr)
+}
+
+func compactNodes(nodes []NodeInfo) []byte {
+	b := make([]byte, 0, len(nodes)*compactNodeLen)
+	for _, node := range nodes {
+		b = AppendCompactNode(b, node)
+	}
+
+	return b
+}
+
+// benByteArrayStructSetter sets fixed size ids and hashes, e.g. NodeID and InfoHash.
+func benByteArrayStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	if l == nil {
+		return ErrInvalidKRPCMessage
+	}
+
+	lval, err := l.String()
+	if err != nil {
+		return err
+	}
+
+	if len(lval.Into()) != obj.Len() {
+		return ErrInvalidHashLength
+	}
+
+	reflect.Copy(obj, reflect.ValueOf([]byte(lval.Into())))
+
+	return nil
+}
+
+// benElementStructSetter keeps arbitrary values, such as the `v` of BEP 44 items, as is.
+func benElementStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	if l != nil {
+		obj.Set(reflect.ValueOf(l))
+	}
+
+	return nil
+}
+
+func benCompactNodesStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	compact, err := l.Bytes()
+	if err != nil {
+		return err
+	}
+
+	size := compactNodeLen
+	if obj.Type() == reflect.TypeFor[CompactNodes6]() {
+		size = compactNode6Len
+	}
+
+	nodes, err := ParseCompactNodes(compact, size)
+	if err != nil {
+		return err
+	}
+
+	obj.Set(reflect.ValueOf(nodes).Convert(obj.Type()))
+
+	return nil
+}
+
+func benPeerValuesStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	list, err := l.List()
+	if err != nil {
+		return err
+	}
+
+	peers := make(PeerValues, 0, len(list.Val))
+
+	for _, elm := range list.Val {
+		compact, err := elm.Bytes()
+		if err != nil {
+			return err
+		}
+
+		peer, err := ParseCompactAddrPort(compact)
+		if err != nil {
+			return err
+		}
+
+		peers = append(peers, peer)
+	}
+
+	obj.Set(reflect.ValueOf(peers))
+
+	return nil
+}
+
+func benKRPCErrorStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	list, err := l.List()
+	if err != nil {
+		return err
+	}
+
+	if len(list.Val) != 2 { //nolint: mnd // [code, message]
+		return ErrInvalidKRPCMessage
+	}
+
+	code, err := list.Val[0].Integer()
+	if err != nil {
+		return err
+	}
+
+	message, err := list.Val[1].String()
+	if err != nil {
+		return err
+	}
+
+	obj.Set(reflect.ValueOf(KRPCError{Code: code.Into(), Message: message.Into()}))
+
+	return nil
+}
+
+func netipAddrPortStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	compact, err := l.Bytes()
+	if err != nil {
+		return err
+	}
+
+	addr, err := ParseCompactAddrPort(compact)
+	if err != nil {
+		return err
+	}
+
+	obj.Set(reflect.ValueOf(addr))
+
+	return nil
+}
+
+func benByteArrayStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	id := make([]byte, obj.Len())
+	reflect.Copy(reflect.ValueOf(id), obj)
+
+	return Str(string(id)), nil
+}
+
+func benElementStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	elm, _ := obj.Interface().(Element)
+	return elm, nil
+}
+
+func benCompactNodesStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	nodes, _ := obj.Convert(reflect.TypeFor[[]NodeInfo]()).Interface().([]NodeInfo)
+	return Str(string(compactNodes(nodes))), nil
+}
+
+func benPeerValuesStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	peers, _ := obj.Interface().(PeerValues)
+
+	list := make([]Element, 0, len(peers))
+	for _, peer := range peers {
+		list = append(list, Str(string(AppendCompactAddrPort(nil, peer))))
+	}
+
+	return Lst(list), nil
+}
+
+func benKRPCErrorStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	krpcErr, _ := obj.Interface().(KRPCError)
+	return Lst([]Element{Int(krpcErr.Code), Str(krpcErr.Message)}), nil
+}
+
+func netipAddrPortStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	addr, _ := obj.Interface().(netip.AddrPort)
+	return Str(string(AppendCompactAddrPort(nil, addr))), nil
+}
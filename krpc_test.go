@@ -0,0 +1,134 @@
+package ben_test
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KRPC", func() {
+	var (
+		queryingID = ben.NodeID([]byte("abcdefghij0123456789"))
+		queriedID  = ben.NodeID([]byte("mnopqrstuvwxyz123456"))
+	)
+
+	DescribeTable("encodes the BEP 5 examples",
+		func(msg ben.KRPCMessage, source string) {
+			encoded, err := msg.MarshalBinary()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(encoded)).To(Equal(source))
+
+			decoded, err := ben.ParseKRPCMessage([]byte(source))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(decoded).To(Equal(msg))
+		},
+		Entry("ping query",
+			ben.NewPingQuery("aa", queryingID),
+			"d1:ad2:id20:abcdefghij0123456789e1:q4:ping1:t2:aa1:y1:qe"),
+		Entry("ping response",
+			ben.NewKRPCResponse("aa", ben.KRPCReturn{ID: queriedID}),
+			"d1:rd2:id20:mnopqrstuvwxyz123456e1:t2:aa1:y1:re"),
+		Entry("error",
+			ben.NewKRPCErrorMessage("aa", ben.KRPCGenericError, "A Generic Error Ocurred"),
+			"d1:eli201e23:A Generic Error Ocurrede1:t2:aa1:y1:ee"),
+		Entry("find_node query",
+			ben.NewFindNodeQuery("aa", queryingID, queriedID),
+			"d1:ad2:id20:abcdefghij01234567896:target20:mnopqrstuvwxyz123456e1:q9:find_node1:t2:aa1:y1:qe"),
+		Entry("get_peers query",
+			ben.NewGetPeersQuery("aa", queryingID, ben.InfoHash(queriedID)),
+			"d1:ad2:id20:abcdefghij01234567899:info_hash20:mnopqrstuvwxyz123456e1:q9:get_peers1:t2:aa1:y1:qe"),
+		Entry("get_peers response with peers",
+			ben.NewKRPCResponse("aa", ben.KRPCReturn{
+				ID:    queryingID,
+				Token: "aoeusnth",
+				Values: ben.PeerValues{
+					netip.MustParseAddrPort("97.120.106.101:11893"),
+					netip.MustParseAddrPort("105.100.104.116:28269"),
+				},
+			}),
+			"d1:rd2:id20:abcdefghij01234567895:token8:aoeusnth6:valuesl6:axje.u6:idhtnmee1:t2:aa1:y1:re"),
+		Entry("announce_peer query",
+			ben.NewAnnouncePeerQuery("aa", queryingID, ben.InfoHash(queriedID), 0, "aoeusnth"),
+			"d1:ad2:id20:abcdefghij012345678912:implied_porti1e9:info_hash20:mnopqrstuvwxyz1234565:token8:aoeusnthe1:q13:announce_peer1:t2:aa1:y1:qe"), //nolint: lll
+	)
+
+	It("decodes compact node info of both address families", func() {
+		nodes := ben.CompactNodes{
+			{ID: queriedID, Addr: netip.MustParseAddrPort("10.0.0.1:6881")},
+			{ID: queryingID, Addr: netip.MustParseAddrPort("10.0.0.2:6882")},
+		}
+		nodes6 := ben.CompactNodes6{
+			{ID: queriedID, Addr: netip.MustParseAddrPort("[2001:db8::1]:6881")},
+		}
+
+		msg := ben.NewKRPCResponse("bb", ben.KRPCReturn{ID: queriedID, Token: "tk", Nodes: nodes, Nodes6: nodes6})
+		msg.IP = netip.MustParseAddrPort("192.0.2.1:4242")
+		msg.Version = "BN01"
+
+		encoded, err := msg.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(encoded)).To(ContainSubstring("5:nodes52:"))
+		Expect(string(encoded)).To(ContainSubstring("6:nodes638:"))
+		Expect(string(encoded)).To(ContainSubstring("2:ip6:\xc0\x00\x02\x01\x10\x92"))
+
+		decoded, err := ben.ParseKRPCMessage(encoded)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded).To(Equal(msg))
+
+		id, ok := decoded.SenderID()
+		Expect(ok).To(BeTrue())
+		Expect(id).To(Equal(queriedID))
+	})
+
+	It("parses compact nodes", func() {
+		compact := "mnopqrstuvwxyz123456\x7f\x00\x00\x01\x1a\xe1"
+
+		nodes, err := ben.ParseCompactNodes([]byte(compact), 26)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodes).To(Equal([]ben.NodeInfo{
+			{ID: queriedID, Addr: netip.MustParseAddrPort("127.0.0.1:6881")},
+		}))
+		Expect(string(ben.AppendCompactNode(nil, nodes[0]))).To(Equal(compact))
+
+		_, err = ben.ParseCompactNodes([]byte(compact[:25]), 26)
+		Expect(err).To(MatchError(ben.ErrInvalidCompactAddr))
+
+		for _, size := range []int{0, -26, 13} {
+			_, err = ben.ParseCompactNodes([]byte(compact), size)
+			Expect(err).To(MatchError(ben.ErrInvalidCompactAddr))
+		}
+	})
+
+	It("decodes the read-only flag", func() {
+		msg, err := ben.ParseKRPCMessage([]byte("d1:ad2:id20:abcdefghij0123456789e1:q4:ping2:roi1e1:t2:aa1:y1:qe"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.ReadOnly).To(BeTrue())
+	})
+
+	It("returns the error of an error message", func() {
+		msg, err := ben.ParseKRPCMessage([]byte("d1:eli204e14:Method Unknowne1:t2:aa1:y1:ee"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Err()).To(MatchError(ben.KRPCError{Code: ben.KRPCMethodUnknown, Message: "Method Unknown"}))
+		Expect(msg.Err().Error()).To(Equal("KRPC error 204: Method Unknown"))
+	})
+
+	DescribeTable("rejects malformed messages",
+		func(source string) {
+			_, err := ben.ParseKRPCMessage([]byte(source))
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("query without arguments", "d1:q4:ping1:t2:aa1:y1:qe"),
+		Entry("response without return values", "d1:t2:aa1:y1:re"),
+		Entry("unknown type", "d1:t2:aa1:y1:xe"),
+		Entry("short node id", "d1:rd2:id3:abce1:t2:aa1:y1:re"),
+		Entry("error without message", "d1:eli201ee1:t2:aa1:y1:ee"),
+		Entry("truncated nodes", "d1:rd2:id20:abcdefghij01234567895:nodes5:"+strings.Repeat("x", 5)+"e1:t2:aa1:y1:re"),
+	)
+})
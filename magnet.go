@@ -0,0 +1,195 @@
+package ben
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	magnetBTIH = "urn:btih:"
+	magnetBTMH = "urn:btmh:"
+	magnetBTPK = "urn:btpk:"
+
+	// multihash prefix of a SHA-256 digest, 0x12 for the function and 0x20 for the length.
+	multihashSHA256 = "1220"
+)
+
+// Magnet is a magnet link (BEP 9), v2 info-hashes are from BEP 52 and
+// public keys of updatable torrents from BEP 46.
+type Magnet struct {
+	InfoHash    InfoHash
+	InfoHashV2  SHA256
+	DisplayName string
+	// Length is the `xl` exact length, zero when unknown.
+	Length   int64
+	Trackers []string
+	WebSeeds []string
+	// Peers are `x.pe` host:port pairs.
+	Peers []string
+
+	PublicKey PublicKey
+	Salt      string
+}
+
+// ParseMagnet parses a magnet URI, info-hashes may be hex or base32 encoded.
+func ParseMagnet(uri string) (Magnet, error) {
+	var m Magnet
+
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "magnet" {
+		return m, ErrInvalidMagnet
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return m, ErrInvalidMagnet
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(query)) {
+		// trackers may be numbered as in `tr.1`
+		name, _, _ := strings.Cut(key, ".")
+		if key == "x.pe" {
+			name = key
+		}
+
+		for _, value := range query[key] {
+			if err := m.set(name, value); err != nil {
+				return m, err
+			}
+		}
+	}
+
+	if m.InfoHash == (InfoHash{}) && m.InfoHashV2 == (SHA256{}) && m.PublicKey == (PublicKey{}) {
+		return m, ErrInvalidMagnet
+	}
+
+	return m, nil
+}
+
+func (m *Magnet) set(name, value string) error {
+	var err error
+
+	switch name {
+	case "xt":
+		err = m.setExactTopic(value)
+
+	case "xs":
+		if key, ok := strings.CutPrefix(value, magnetBTPK); ok {
+			err = decodeHexInto(m.PublicKey[:], key)
+		}
+
+	case "s":
+		var salt []byte
+
+		salt, err = hex.DecodeString(value)
+		m.Salt = string(salt)
+
+	case "dn":
+		m.DisplayName = value
+
+	case "xl":
+		m.Length, err = strconv.ParseInt(value, 10, 64)
+
+	case "tr":
+		m.Trackers = append(m.Trackers, value)
+
+	case "ws":
+		m.WebSeeds = append(m.WebSeeds, value)
+
+	case "x.pe":
+		m.Peers = append(m.Peers, value)
+	}
+
+	if err != nil {
+		return ErrInvalidMagnet
+	}
+
+	return nil
+}
+
+func (m *Magnet) setExactTopic(value string) error {
+	if hash, ok := strings.CutPrefix(value, magnetBTIH); ok {
+		if len(hash) == base32.StdEncoding.EncodedLen(len(InfoHash{})) {
+			decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash))
+			if err != nil {
+				return err
+			}
+
+			m.InfoHash = InfoHash(decoded)
+
+			return nil
+		}
+
+		return decodeHexInto(m.InfoHash[:], hash)
+	}
+
+	if hash, ok := strings.CutPrefix(value, magnetBTMH+multihashSHA256); ok {
+		return decodeHexInto(m.InfoHashV2[:], hash)
+	}
+
+	// other hash types are not supported, they are ignored like unknown parameters
+	return nil
+}
+
+func decodeHexInto(dst []byte, s string) error {
+	if hex.DecodedLen(len(s)) != len(dst) {
+		return ErrInvalidHashLength
+	}
+
+	_, err := hex.Decode(dst, []byte(s))
+
+	return err
+}
+
+// IsUpdatable reports whether the magnet points to a BEP 46 updatable torrent.
+func (m Magnet) IsUpdatable() bool {
+	return m.PublicKey != PublicKey{}
+}
+
+// String returns the magnet URI, hashes are hex encoded.
+func (m Magnet) String() string {
+	var params []string
+
+	if m.InfoHash != (InfoHash{}) {
+		params = append(params, "xt="+magnetBTIH+m.InfoHash.String())
+	}
+
+	if m.InfoHashV2 != (SHA256{}) {
+		params = append(params, "xt="+magnetBTMH+multihashSHA256+hex.EncodeToString(m.InfoHashV2[:]))
+	}
+
+	if m.IsUpdatable() {
+		params = append(params, "xs="+magnetBTPK+hex.EncodeToString(m.PublicKey[:]))
+	}
+
+	if m.Salt != "" {
+		params = append(params, "s="+hex.EncodeToString([]byte(m.Salt)))
+	}
+
+	if m.DisplayName != "" {
+		params = append(params, "dn="+url.QueryEscape(m.DisplayName))
+	}
+
+	if m.Length > 0 {
+		params = append(params, "xl="+strconv.FormatInt(m.Length, 10))
+	}
+
+	for _, tracker := range m.Trackers {
+		params = append(params, "tr="+url.QueryEscape(tracker))
+	}
+
+	for _, webSeed := range m.WebSeeds {
+		params = append(params, "ws="+url.QueryEscape(webSeed))
+	}
+
+	for _, peer := range m.Peers {
+		params = append(params, "x.pe="+url.QueryEscape(peer))
+	}
+
+	return "magnet:?" + strings.Join(params, "&")
+}
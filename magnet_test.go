@@ -0,0 +1,72 @@
+package ben_test
+
+import (
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Magnet", func() {
+	const hexHash = "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+
+	It("parses hex info-hashes, trackers and peers", func() {
+		m, err := ben.ParseMagnet("magnet:?xt=urn:btih:" + hexHash +
+			"&dn=Some+Name&xl=1024&tr.2=udp%3A%2F%2Fb%3A80&tr.1=http%3A%2F%2Fa%2Fannounce" +
+			"&ws=http%3A%2F%2Fseed%2F&x.pe=10.0.0.1%3A6881")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.InfoHash.String()).To(Equal(hexHash))
+		Expect(m.DisplayName).To(Equal("Some Name"))
+		Expect(m.Length).To(Equal(int64(1024)))
+		Expect(m.Trackers).To(Equal([]string{"http://a/announce", "udp://b:80"}))
+		Expect(m.WebSeeds).To(Equal([]string{"http://seed/"}))
+		Expect(m.Peers).To(Equal([]string{"10.0.0.1:6881"}))
+		Expect(m.IsUpdatable()).To(BeFalse())
+
+		again, err := ben.ParseMagnet(m.String())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(Equal(m))
+	})
+
+	It("parses base32 info-hashes", func() {
+		m, err := ben.ParseMagnet("magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.InfoHash.String()).To(Equal(hexHash))
+	})
+
+	It("parses hybrid magnet links", func() {
+		v2 := "caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e"
+
+		m, err := ben.ParseMagnet("magnet:?xt=urn:btih:" + hexHash + "&xt=urn:btmh:1220" + v2)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.InfoHash.String()).To(Equal(hexHash))
+		Expect(m.InfoHashV2[:]).To(Equal(mustDecodeHex(v2)))
+		Expect(m.String()).To(Equal("magnet:?xt=urn:btih:" + hexHash + "&xt=urn:btmh:1220" + v2))
+	})
+
+	It("parses updatable magnet links", func() {
+		key := "8543d3e6115f0f98c944077a4493dcd543e49c739fd998550a1f614ab36ed63e"
+
+		m, err := ben.ParseMagnet("magnet:?xs=urn:btpk:" + key + "&s=6e616d65")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.IsUpdatable()).To(BeTrue())
+		Expect(m.PublicKey[:]).To(Equal(mustDecodeHex(key)))
+		Expect(m.Salt).To(Equal("name"))
+		Expect(m.String()).To(Equal("magnet:?xs=urn:btpk:" + key + "&s=6e616d65"))
+	})
+
+	DescribeTable("rejects invalid links",
+		func(uri string) {
+			_, err := ben.ParseMagnet(uri)
+			Expect(err).To(MatchError(ben.ErrInvalidMagnet))
+		},
+		Entry("other scheme", "http://example.com/?xt=urn:btih:"+hexHash),
+		Entry("without topic", "magnet:?dn=name"),
+		Entry("short hash", "magnet:?xt=urn:btih:c12fe1"),
+		Entry("bad hex", "magnet:?xt=urn:btih:"+hexHash[:38]+"zz"),
+		Entry("bad salt", "magnet:?xt=urn:btih:"+hexHash+"&s=xyz"),
+	)
+})
@@ -0,0 +1,253 @@
+package ben
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// BlockSize is the size of a merkle tree leaf in BitTorrent v2.
+const BlockSize = 16 * 1024
+
+// maxHashRequestLength is the largest number of hashes a hash request may ask for.
+const maxHashRequestLength = 512
+
+// MerkleTree is the SHA-256 merkle tree of a single file in BitTorrent v2 (BEP 52).
+// Leaves are the hashes of 16 KiB blocks, padded with zero hashes up to a power of two.
+type MerkleTree struct {
+	// layers[0] holds the leaves, the last layer holds the root only.
+	layers [][]SHA256
+}
+
+func NewMerkleTree(leaves []SHA256) *MerkleTree {
+	layer := padLeaves(leaves)
+	layers := [][]SHA256{layer}
+
+	for len(layer) > 1 {
+		layer = parentLayer(layer)
+		layers = append(layers, layer)
+	}
+
+	return &MerkleTree{layers: layers}
+}
+
+// MerkleTreeFromReader reads r until EOF and builds the merkle tree of its content.
+func MerkleTreeFromReader(r io.Reader) (*MerkleTree, int64, error) {
+	leaves, length, err := hashBlocks(r)
+	if err != nil {
+		return nil, length, err
+	}
+
+	return NewMerkleTree(leaves), length, nil
+}
+
+// Root returns the `pieces root` of the file.
+func (t *MerkleTree) Root() SHA256 {
+	return t.layers[len(t.layers)-1][0]
+}
+
+// Height returns the number of layers, the root layer included.
+func (t *MerkleTree) Height() int {
+	return len(t.layers)
+}
+
+// Layer returns the hashes of the given layer, counted from the leaves.
+func (t *MerkleTree) Layer(layer int) []SHA256 {
+	if layer < 0 || layer >= len(t.layers) {
+		return nil
+	}
+
+	return t.layers[layer]
+}
+
+// PieceLayer returns the piece layer for a file of length bytes,
+// nil when the whole file fits in a single piece.
+func (t *MerkleTree) PieceLayer(pieceLength, length int64) []SHA256 {
+	if length <= pieceLength {
+		return nil
+	}
+
+	layer := bits.TrailingZeros64(uint64(pieceLength / BlockSize))
+	pieceCount := (length + pieceLength - 1) / pieceLength
+
+	return t.Layer(layer)[:pieceCount]
+}
+
+// HashRequest asks for hashes of a file merkle tree, as in the BEP 52 `hash request` message.
+type HashRequest struct {
+	PiecesRoot SHA256
+	// BaseLayer is the layer of the requested hashes, counted from the leaves.
+	BaseLayer int
+	// Index is the offset of the first requested hash, it must be a multiple of Length.
+	Index int
+	// Length is the number of requested hashes, a power of two.
+	Length int
+	// ProofLayers is the number of uncle hashes to include, from the bottom up.
+	ProofLayers int
+}
+
+// subtree returns the layer and the index of the node covering all requested hashes.
+func (r HashRequest) subtree() (int, int) {
+	return r.BaseLayer + bits.TrailingZeros(uint(r.Length)), r.Index / r.Length
+}
+
+func (r HashRequest) validate(height int) error {
+	if r.Length < 1 || r.Length > maxHashRequestLength || r.Length&(r.Length-1) != 0 ||
+		r.Index < 0 || r.Index%r.Length != 0 || r.BaseLayer < 0 || r.ProofLayers < 0 {
+		return ErrInvalidHashRequest
+	}
+
+	layer, _ := r.subtree()
+	if layer+r.ProofLayers >= height {
+		return ErrInvalidHashRequest
+	}
+
+	return nil
+}
+
+// Hashes answers a hash request with the requested hashes followed by the uncle hashes.
+// An error means the request should be answered with a `hash reject`,
+// which includes requests for another file than the one of the tree.
+func (t *MerkleTree) Hashes(req HashRequest) ([]SHA256, error) {
+	if req.PiecesRoot != t.Root() {
+		return nil, ErrInvalidHashRequest
+	}
+
+	if err := req.validate(t.Height()); err != nil {
+		return nil, err
+	}
+
+	base := t.layers[req.BaseLayer]
+	if req.Index+req.Length > len(base) {
+		return nil, ErrInvalidHashRequest
+	}
+
+	hashes := append([]SHA256(nil), base[req.Index:req.Index+req.Length]...)
+
+	layer, idx := req.subtree()
+	for range req.ProofLayers {
+		hashes = append(hashes, t.layers[layer][idx^1])
+		layer++
+		idx /= 2
+	}
+
+	return hashes, nil
+}
+
+// VerifyHashes checks hashes received for req, the requested ones followed by the uncles.
+// Uncle hashes must reach the layer of root, which is the pieces root when
+// the proof spans the whole tree, or an already verified node otherwise.
+func VerifyHashes(root SHA256, req HashRequest, hashes []SHA256) error {
+	if req.Length < 1 || req.Length&(req.Length-1) != 0 || req.Index < 0 || req.Index%req.Length != 0 ||
+		req.ProofLayers < 0 || len(hashes) != req.Length+req.ProofLayers {
+		return ErrInvalidHashRequest
+	}
+
+	layer := hashes[:req.Length]
+	for len(layer) > 1 {
+		layer = parentLayer(layer)
+	}
+
+	node := layer[0]
+	_, idx := req.subtree()
+
+	for _, uncle := range hashes[req.Length:] {
+		if idx%2 == 0 {
+			node = hashPair(node, uncle)
+		} else {
+			node = hashPair(uncle, node)
+		}
+
+		idx /= 2
+	}
+
+	if node != root {
+		return ErrHashMismatch
+	}
+
+	return nil
+}
+
+// BlockProof returns the uncle hashes needed to verify a single 16 KiB block against the root.
+func (t *MerkleTree) BlockProof(block int) ([]SHA256, error) {
+	hashes, err := t.Hashes(HashRequest{
+		PiecesRoot:  t.Root(),
+		Index:       block,
+		Length:      1,
+		ProofLayers: t.Height() - 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes[1:], nil
+}
+
+// VerifyBlock checks a 16 KiB block of a file against its pieces root.
+func VerifyBlock(root SHA256, block int, data []byte, proof []SHA256) error {
+	req := HashRequest{PiecesRoot: root, Index: block, Length: 1, ProofLayers: len(proof)}
+	return VerifyHashes(root, req, append([]SHA256{sha256.Sum256(data)}, proof...))
+}
+
+func hashPair(left, right SHA256) SHA256 {
+	var buff [2 * sha256.Size]byte
+
+	copy(buff[:sha256.Size], left[:])
+	copy(buff[sha256.Size:], right[:])
+
+	return sha256.Sum256(buff[:])
+}
+
+func nextPowerOfTwo(n int) int {
+	if n <= 1 {
+		return 1
+	}
+
+	return 1 << bits.Len(uint(n-1))
+}
+
+// parentLayer hashes each pair of nodes, layer length must be even.
+func parentLayer(layer []SHA256) []SHA256 {
+	parents := make([]SHA256, len(layer)/2) //nolint: mnd // pairs
+	for i := range parents {
+		parents[i] = hashPair(layer[2*i], layer[2*i+1])
+	}
+
+	return parents
+}
+
+// padLeaves pads leaves with zero hashes up to the next power of two.
+func padLeaves(leaves []SHA256) []SHA256 {
+	padded := make([]SHA256, nextPowerOfTwo(len(leaves)))
+	copy(padded, leaves)
+
+	return padded
+}
+
+// hashBlocks reads r until EOF and returns the SHA-256 of every 16 KiB block,
+// the last block is hashed as is without padding.
+func hashBlocks(r io.Reader) ([]SHA256, int64, error) {
+	var (
+		leaves []SHA256
+		length int64
+	)
+
+	buff := make([]byte, BlockSize)
+
+	for {
+		n, err := io.ReadFull(r, buff)
+		if n > 0 {
+			leaves = append(leaves, sha256.Sum256(buff[:n]))
+			length += int64(n)
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return leaves, length, nil
+		}
+
+		if err != nil {
+			return leaves, length, err
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package ben_test
+
+import (
+	"bytes"
+	"math/rand/v2"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Merkle tree", func() {
+	var (
+		data []byte
+		tree *ben.MerkleTree
+	)
+
+	BeforeEach(func() {
+		rng := rand.New(rand.NewPCG(52, 52))
+		data = make([]byte, 10*ben.BlockSize+100)
+		for i := range data {
+			data[i] = byte(rng.Uint32())
+		}
+
+		var (
+			length int64
+			err    error
+		)
+
+		tree, length, err = ben.MerkleTreeFromReader(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(length).To(Equal(int64(len(data))))
+	})
+
+	It("pads the leaves up to a power of two", func() {
+		Expect(tree.Layer(0)).To(HaveLen(16))
+		Expect(tree.Layer(0)[11]).To(BeZero())
+		Expect(tree.Height()).To(Equal(5))
+	})
+
+	It("verifies every block with its proof", func() {
+		for block := range 11 {
+			proof, err := tree.BlockProof(block)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proof).To(HaveLen(4))
+
+			end := min((block+1)*ben.BlockSize, len(data))
+			Expect(ben.VerifyBlock(tree.Root(), block, data[block*ben.BlockSize:end], proof)).To(Succeed())
+		}
+	})
+
+	It("rejects a tampered block", func() {
+		proof, err := tree.BlockProof(3)
+		Expect(err).NotTo(HaveOccurred())
+
+		block := bytes.Clone(data[3*ben.BlockSize : 4*ben.BlockSize])
+		block[0]++
+		Expect(ben.VerifyBlock(tree.Root(), 3, block, proof)).To(MatchError(ben.ErrHashMismatch))
+	})
+
+	It("answers hash requests with a full proof", func() {
+		req := ben.HashRequest{PiecesRoot: tree.Root(), BaseLayer: 0, Index: 4, Length: 4, ProofLayers: 2}
+
+		hashes, err := tree.Hashes(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hashes).To(HaveLen(6))
+		Expect(hashes[:4]).To(Equal(tree.Layer(0)[4:8]))
+		Expect(ben.VerifyHashes(tree.Root(), req, hashes)).To(Succeed())
+	})
+
+	It("verifies partial proofs against an already known node", func() {
+		req := ben.HashRequest{PiecesRoot: tree.Root(), BaseLayer: 1, Index: 6, Length: 2, ProofLayers: 1}
+
+		hashes, err := tree.Hashes(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ben.VerifyHashes(tree.Layer(3)[1], req, hashes)).To(Succeed())
+		Expect(ben.VerifyHashes(tree.Root(), req, hashes)).To(MatchError(ben.ErrHashMismatch))
+	})
+
+	DescribeTable("rejects invalid hash requests",
+		func(req ben.HashRequest) {
+			req.PiecesRoot = tree.Root()
+			_, err := tree.Hashes(req)
+			Expect(err).To(MatchError(ben.ErrInvalidHashRequest))
+		},
+		Entry("length is not a power of two", ben.HashRequest{Index: 0, Length: 3}),
+		Entry("index is not a multiple of length", ben.HashRequest{Index: 2, Length: 4}),
+		Entry("too many proof layers", ben.HashRequest{Index: 0, Length: 4, ProofLayers: 3}),
+		Entry("index out of the layer", ben.HashRequest{Index: 16, Length: 4}),
+		Entry("base layer above the root", ben.HashRequest{BaseLayer: 5, Length: 1}),
+	)
+
+	It("rejects hash requests for another file", func() {
+		_, err := tree.Hashes(ben.HashRequest{PiecesRoot: ben.SHA256{1}, Index: 0, Length: 4, ProofLayers: 2})
+		Expect(err).To(MatchError(ben.ErrInvalidHashRequest))
+	})
+
+	DescribeTable("rejects invalid requests when verifying",
+		func(req ben.HashRequest, count int) {
+			Expect(ben.VerifyHashes(tree.Root(), req, make([]ben.SHA256, count))).To(MatchError(ben.ErrInvalidHashRequest))
+		},
+		Entry("negative proof layers", ben.HashRequest{Index: 0, Length: 2, ProofLayers: -1}, 1),
+		Entry("negative index", ben.HashRequest{Index: -1, Length: 1, ProofLayers: 4}, 5),
+	)
+})
@@ -0,0 +1,213 @@
+package ben
+
+import (
+	"crypto/sha1" //nolint: gosec // mandated by BEP 9
+	"crypto/sha256"
+	"sync"
+)
+
+// maxMetadataSize bounds the size announced by peers, real info dictionaries are far smaller.
+const maxMetadataSize = 16 * 1024 * 1024
+
+// MetadataAssembler collects the pieces of an info dictionary sent by peers with ut_metadata
+// (BEP 9), and checks the result against the expected info-hashes. It is safe for concurrent use,
+// so pieces can come from any number of peers.
+type MetadataAssembler struct {
+	infoHash   InfoHash
+	infoHashV2 SHA256
+
+	mu       sync.Mutex
+	size     int64
+	pieces   [][]byte
+	received int
+}
+
+// NewMetadataAssembler expects the metadata of infoHash, infoHashV2 or both,
+// a zero hash is not checked.
+func NewMetadataAssembler(infoHash InfoHash, infoHashV2 SHA256) *MetadataAssembler {
+	return &MetadataAssembler{infoHash: infoHash, infoHashV2: infoHashV2}
+}
+
+// NewMetadataAssemblerForMagnet expects the metadata of the info-hashes of m.
+func NewMetadataAssemblerForMagnet(m Magnet) *MetadataAssembler {
+	return NewMetadataAssembler(m.InfoHash, m.InfoHashV2)
+}
+
+// SetSize sets the size of the metadata, as announced by the `metadata_size` of an extension
+// handshake. A size differing from the one already known is rejected, its sender should not
+// be trusted.
+func (a *MetadataAssembler) SetSize(size int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.setSize(size)
+}
+
+func (a *MetadataAssembler) setSize(size int64) error {
+	if size <= 0 || size > maxMetadataSize || a.size != 0 && size != a.size {
+		return ErrInvalidMetadataSize
+	}
+
+	if a.size == 0 {
+		a.size = size
+		a.pieces = make([][]byte, (size+MetadataPieceSize-1)/MetadataPieceSize)
+	}
+
+	return nil
+}
+
+// Size returns the size of the metadata, zero while unknown.
+func (a *MetadataAssembler) Size() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.size
+}
+
+// Add stores the piece of a data message, msg and data are as returned by ParseMetadataMessage.
+func (a *MetadataAssembler) Add(msg MetadataMessage, data []byte) error {
+	if msg.Type != MetadataData {
+		return ErrInvalidMetadataMessage
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.setSize(msg.TotalSize); err != nil {
+		return err
+	}
+
+	if msg.Piece < 0 || msg.Piece >= int64(len(a.pieces)) || int64(len(data)) != a.pieceLen(msg.Piece) {
+		return ErrInvalidMetadataPiece
+	}
+
+	if a.pieces[msg.Piece] == nil {
+		a.pieces[msg.Piece] = append([]byte(nil), data...)
+		a.received++
+	}
+
+	return nil
+}
+
+func (a *MetadataAssembler) pieceLen(piece int64) int64 {
+	return min(a.size-piece*MetadataPieceSize, MetadataPieceSize)
+}
+
+// Missing returns the pieces still to be requested, nil while the size is unknown.
+func (a *MetadataAssembler) Missing() []int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var missing []int64
+
+	for piece, data := range a.pieces {
+		if data == nil {
+			missing = append(missing, int64(piece))
+		}
+	}
+
+	return missing
+}
+
+// Complete reports whether every piece has been received.
+func (a *MetadataAssembler) Complete() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.complete()
+}
+
+func (a *MetadataAssembler) complete() bool {
+	return a.size > 0 && a.received == len(a.pieces)
+}
+
+// Metadata returns the assembled bencoded info dictionary once complete and verified.
+// When it does not match the info-hashes every piece is dropped along with the size, as the bad
+// ones cannot be told apart, and they have to be requested again.
+func (a *MetadataAssembler) Metadata() ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.complete() {
+		return nil, ErrMetadataIncomplete
+	}
+
+	metadata := make([]byte, 0, a.size)
+	for _, data := range a.pieces {
+		metadata = append(metadata, data...)
+	}
+
+	if !a.matches(metadata) {
+		a.size = 0
+		a.pieces = nil
+		a.received = 0
+
+		return nil, ErrMetadataHashMismatch
+	}
+
+	return metadata, nil
+}
+
+func (a *MetadataAssembler) matches(metadata []byte) bool {
+	if a.infoHash != (InfoHash{}) && sha1.Sum(metadata) != a.infoHash { //nolint: gosec // see import
+		return false
+	}
+
+	if a.infoHashV2 != (SHA256{}) && sha256.Sum256(metadata) != a.infoHashV2 {
+		return false
+	}
+
+	return true
+}
+
+// Info decodes the assembled metadata once complete and verified. Entries not modelled in Info
+// are kept in its Extra, the verified bytes returned by Metadata remain the reference though,
+// as an info dictionary with its keys out of order does not encode back to them.
+func (a *MetadataAssembler) Info() (Info, error) {
+	metadata, err := a.Metadata()
+	if err != nil {
+		return Info{}, err
+	}
+
+	return decodeMetadata(metadata)
+}
+
+func decodeMetadata(metadata []byte) (Info, error) {
+	dict, n, err := DecodePrefix[Dictionary](metadata)
+	if err != nil {
+		return Info{}, err
+	}
+
+	if n != len(metadata) {
+		return Info{}, ErrInvalidMetadataPiece
+	}
+
+	return Info{}.TryFrom(dict)
+}
+
+// Torrent builds a torrent from the assembled metadata, with the trackers and web seeds of m.
+// Every tracker of the magnet link gets its own tier. The verified metadata is kept in RawInfo,
+// so the torrent encodes back to the same info-hash.
+func (a *MetadataAssembler) Torrent(m Magnet) (Torrent, error) {
+	metadata, err := a.Metadata()
+	if err != nil {
+		return Torrent{}, err
+	}
+
+	info, err := decodeMetadata(metadata)
+	if err != nil {
+		return Torrent{}, err
+	}
+
+	torrent := Torrent{Info: info, RawInfo: metadata, URLList: m.WebSeeds}
+
+	for _, tracker := range m.Trackers {
+		if torrent.Announce == "" {
+			torrent.Announce = tracker
+		}
+
+		torrent.AnnounceList = append(torrent.AnnounceList, []string{tracker})
+	}
+
+	return torrent, nil
+}
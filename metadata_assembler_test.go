@@ -0,0 +1,182 @@
+package ben_test
+
+import (
+	"crypto/sha1" //nolint: gosec // mandated by BEP 9
+	"crypto/sha256"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metadata assembler", func() {
+	var (
+		info     ben.Info
+		metadata []byte
+		pieces   [][]byte
+	)
+
+	dataMessage := func(piece int) ben.MetadataMessage {
+		return ben.MetadataMessage{Type: ben.MetadataData, Piece: int64(piece), TotalSize: int64(len(metadata))}
+	}
+
+	BeforeEach(func() {
+		info = ben.Info{Name: "big.iso", PieceLength: 16384, Length: 1000 * 16384}
+		for i := range 1000 {
+			hash := sha1.Sum([]byte{byte(i)}) //nolint: gosec // see import
+			info.Pieces = append(info.Pieces, hash[:])
+		}
+
+		dict, err := info.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+
+		metadata = dict.Encode()
+		pieces = [][]byte{metadata[:ben.MetadataPieceSize], metadata[ben.MetadataPieceSize:]}
+	})
+
+	It("assembles pieces received in any order", func() {
+		assembler := ben.NewMetadataAssembler(sha1.Sum(metadata), ben.SHA256{}) //nolint: gosec // see import
+		Expect(assembler.Missing()).To(BeNil())
+
+		Expect(assembler.SetSize(int64(len(metadata)))).To(Succeed())
+		Expect(assembler.Missing()).To(Equal([]int64{0, 1}))
+
+		Expect(assembler.Add(dataMessage(1), pieces[1])).To(Succeed())
+		Expect(assembler.Missing()).To(Equal([]int64{0}))
+		Expect(assembler.Complete()).To(BeFalse())
+
+		_, err := assembler.Info()
+		Expect(err).To(MatchError(ben.ErrMetadataIncomplete))
+
+		Expect(assembler.Add(dataMessage(0), pieces[0])).To(Succeed())
+		Expect(assembler.Complete()).To(BeTrue())
+
+		assembled, err := assembler.Info()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(assembled).To(Equal(info))
+	})
+
+	It("checks v2 info-hashes", func() {
+		assembler := ben.NewMetadataAssembler(ben.InfoHash{}, sha256.Sum256(metadata))
+
+		for i, piece := range pieces {
+			Expect(assembler.Add(dataMessage(i), piece)).To(Succeed())
+		}
+
+		raw, err := assembler.Metadata()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(raw).To(Equal(metadata))
+	})
+
+	It("drops every piece and the size on a hash mismatch", func() {
+		assembler := ben.NewMetadataAssembler(ben.InfoHash{1}, ben.SHA256{})
+
+		for i, piece := range pieces {
+			Expect(assembler.Add(dataMessage(i), piece)).To(Succeed())
+		}
+
+		_, err := assembler.Info()
+		Expect(err).To(MatchError(ben.ErrMetadataHashMismatch))
+		Expect(assembler.Size()).To(BeZero())
+		Expect(assembler.Missing()).To(BeNil())
+	})
+
+	It("accepts the metadata of a good peer after a bad one announced another size", func() {
+		assembler := ben.NewMetadataAssembler(sha1.Sum(metadata), ben.SHA256{}) //nolint: gosec // see import
+
+		bad := ben.MetadataMessage{Type: ben.MetadataData, TotalSize: 3}
+		Expect(assembler.Add(bad, []byte("abc"))).To(Succeed())
+
+		_, err := assembler.Metadata()
+		Expect(err).To(MatchError(ben.ErrMetadataHashMismatch))
+
+		for i, piece := range pieces {
+			Expect(assembler.Add(dataMessage(i), piece)).To(Succeed())
+		}
+
+		assembled, err := assembler.Info()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(assembled).To(Equal(info))
+	})
+
+	It("rejects inconsistent sizes and pieces", func() {
+		assembler := ben.NewMetadataAssembler(sha1.Sum(metadata), ben.SHA256{}) //nolint: gosec // see import
+		Expect(assembler.SetSize(int64(len(metadata)))).To(Succeed())
+
+		Expect(assembler.SetSize(int64(len(metadata) + 1))).To(MatchError(ben.ErrInvalidMetadataSize))
+		Expect(assembler.Add(dataMessage(2), pieces[1])).To(MatchError(ben.ErrInvalidMetadataPiece))
+		Expect(assembler.Add(dataMessage(1), pieces[0])).To(MatchError(ben.ErrInvalidMetadataPiece))
+		Expect(assembler.Add(ben.MetadataMessage{Type: ben.MetadataReject}, nil)).To(MatchError(ben.ErrInvalidMetadataMessage))
+		Expect(assembler.Size()).To(Equal(int64(len(metadata))))
+	})
+
+	It("keeps the info-hash of info dictionaries with unmodelled entries", func() {
+		raw := []byte("d6:lengthi6e6:md5sum32:0123456789abcdef0123456789abcdef4:name3:abc" +
+			"10:name.utf-83:abc12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaa9:publisher3:fooe")
+		magnet := ben.Magnet{InfoHash: sha1.Sum(raw)} //nolint: gosec // see import
+
+		assembler := ben.NewMetadataAssemblerForMagnet(magnet)
+		Expect(assembler.Add(ben.MetadataMessage{Type: ben.MetadataData, TotalSize: int64(len(raw))}, raw)).To(Succeed())
+
+		torrent, err := assembler.Torrent(magnet)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(torrent.Info.Extra).To(HaveKey("name.utf-8"))
+
+		hash, err := torrent.Info.Hash()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hash).To(Equal(magnet.InfoHash))
+
+		dict, err := torrent.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dict.Val["info"].Encode()).To(Equal(raw))
+	})
+
+	It("keeps metadata that does not encode back to the same bytes", func() {
+		raw := []byte("d4:name3:abc6:lengthi6ee")
+		magnet := ben.Magnet{InfoHash: sha1.Sum(raw)} //nolint: gosec // see import
+
+		assembler := ben.NewMetadataAssemblerForMagnet(magnet)
+		Expect(assembler.Add(ben.MetadataMessage{Type: ben.MetadataData, TotalSize: int64(len(raw))}, raw)).To(Succeed())
+
+		info, err := assembler.Info()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Name).To(Equal("abc"))
+		Expect(info.Length).To(Equal(int64(6)))
+
+		torrent, err := assembler.Torrent(magnet)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(torrent.RawInfo).To(Equal(raw))
+
+		dict, err := torrent.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(ContainSubstring("4:info" + string(raw)))
+	})
+
+	It("builds a torrent with the trackers of a magnet link", func() {
+		magnet := ben.Magnet{
+			InfoHash: sha1.Sum(metadata), //nolint: gosec // see import
+			Trackers: []string{"http://a/announce", "udp://b:6969"},
+			WebSeeds: []string{"http://c/big.iso"},
+		}
+
+		assembler := ben.NewMetadataAssemblerForMagnet(magnet)
+		for i, piece := range pieces {
+			Expect(assembler.Add(dataMessage(i), piece)).To(Succeed())
+		}
+
+		torrent, err := assembler.Torrent(magnet)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(torrent.Info).To(Equal(info))
+		Expect(torrent.Announce).To(Equal("http://a/announce"))
+		Expect(torrent.Trackers()).To(Equal(ben.AnnounceList{{"http://a/announce"}, {"udp://b:6969"}}))
+		Expect(torrent.URLList).To(Equal(ben.URLList{"http://c/big.iso"}))
+
+		hash, err := torrent.Info.Hash()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hash).To(Equal(magnet.InfoHash))
+	})
+})
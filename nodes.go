@@ -0,0 +1,90 @@
+package ben
+
+import (
+	"maps"
+	"math"
+	"net"
+	"strconv"
+)
+
+// DHTNode is a bootstrap node from the `nodes` key of a trackerless torrent (BEP 5).
+type DHTNode struct {
+	Host string
+	Port int
+}
+
+// Addr returns the node address in host:port form, ready for net.Dial.
+func (n DHTNode) Addr() string {
+	return net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+}
+
+// NodeAddrs returns the address of every bootstrap node in host:port form.
+func (t Torrent) NodeAddrs() []string {
+	addrs := make([]string, 0, len(t.Nodes))
+	for _, node := range t.Nodes {
+		addrs = append(addrs, node.Addr())
+	}
+
+	return addrs
+}
+
+// IsTrackerless reports whether peers can only be found through the DHT.
+func (t Torrent) IsTrackerless() bool {
+	return len(t.Trackers()) == 0
+}
+
+// dhtNodeFrom decodes a [host, port] pair of the `nodes` list.
+func dhtNodeFrom(l Element) (DHTNode, error) {
+	list, err := l.List()
+	if err != nil {
+		return DHTNode{}, err
+	}
+
+	if len(list.Val) != 2 {
+		return DHTNode{}, ErrInvalidDHTNode
+	}
+
+	host, err := list.Val[0].String()
+	if err != nil {
+		return DHTNode{}, err
+	}
+
+	port, err := list.Val[1].Integer()
+	if err != nil {
+		return DHTNode{}, err
+	}
+
+	if port.Into() < 1 || port.Into() > math.MaxUint16 {
+		return DHTNode{}, ErrInvalidDHTNode
+	}
+
+	return DHTNode{Host: host.Into(), Port: int(port.Into())}, nil
+}
+
+// withValidNodes leaves the malformed entries out of the `nodes` of torrent, they are only
+// a hint to join the DHT. Without any valid one the key is left out altogether.
+func withValidNodes(torrent Dictionary) Dictionary {
+	elm, ok := torrent.Val["nodes"]
+	if !ok {
+		return torrent
+	}
+
+	var nodes []Element
+
+	if list, err := elm.List(); err == nil {
+		for _, node := range list.Val {
+			if _, err := dhtNodeFrom(node); err == nil {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+
+	valid := maps.Clone(torrent.Val)
+	if len(nodes) > 0 {
+		valid["nodes"] = Lst(nodes)
+	} else {
+		delete(valid, "nodes")
+	}
+
+	return Dct(valid)
+}
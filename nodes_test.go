@@ -0,0 +1,60 @@
+package ben_test
+
+import (
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Trackerless torrent", func() {
+	const source = "d4:infod4:name3:abc6:pieces0:e5:nodesll9:127.0.0.1i6881eel3:::1i6882eeee"
+
+	It("decodes bootstrap nodes", func() {
+		torrent, err := decodeTorrent(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(torrent.IsTrackerless()).To(BeTrue())
+		Expect(torrent.Nodes).To(Equal([]ben.DHTNode{
+			{Host: "127.0.0.1", Port: 6881},
+			{Host: "::1", Port: 6882},
+		}))
+		Expect(torrent.NodeAddrs()).To(Equal([]string{"127.0.0.1:6881", "[::1]:6882"}))
+	})
+
+	It("encodes bootstrap nodes back", func() {
+		torrent, err := decodeTorrent(source)
+		Expect(err).NotTo(HaveOccurred())
+
+		dict, err := torrent.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(Equal(source))
+	})
+
+	DescribeTable("skips malformed nodes",
+		func(nodes string) {
+			torrent, err := decodeTorrent("d4:infod4:name3:abc6:pieces0:e5:nodesl" + nodes + "l3:::1i6882eeee")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(torrent.Nodes).To(Equal([]ben.DHTNode{{Host: "::1", Port: 6882}}))
+		},
+		Entry("without port", "l9:127.0.0.1e"),
+		Entry("port zero", "l9:127.0.0.1i0ee"),
+		Entry("port out of range", "l9:127.0.0.1i65536ee"),
+		Entry("negative port", "l9:127.0.0.1i-1ee"),
+		Entry("not a list", "9:127.0.0.1"),
+	)
+
+	It("keeps nodes without any valid entry as they were", func() {
+		source := "d4:infod4:name3:abc6:pieces0:e5:nodes3:abce"
+
+		torrent, err := decodeTorrent(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(torrent.Nodes).To(BeNil())
+
+		dict, err := torrent.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(Equal(source))
+	})
+})
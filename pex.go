@@ -0,0 +1,266 @@
+package ben
+
+import (
+	"bufio"
+	"bytes"
+	"net/netip"
+	"reflect"
+	"slices"
+)
+
+// ExtensionUTPex is the extension name of peer exchange in the handshake (BEP 11).
+const ExtensionUTPex = "ut_pex"
+
+// maxPEXPeers is the largest number of added, and of dropped, peers in a message (BEP 11).
+const maxPEXPeers = 50
+
+// PEXFlags describe an added peer, as in the `added.f` and `added6.f` of PEX messages.
+type PEXFlags byte
+
+const (
+	PEXEncryption PEXFlags = 1 << iota
+	PEXSeed
+	PEXUTP
+	PEXHolepunch
+	PEXOutgoing
+)
+
+// PEXFlagList holds one PEXFlags per added peer, sent as a string of bytes.
+type PEXFlagList []PEXFlags
+
+// Peers4 are compact IPv4 peers, unlike Peers they are never written as a list of dictionaries.
+type Peers4 []netip.AddrPort
+
+// PEXMessage is a ut_pex message (BEP 11), the flags of the added peers are in the same order.
+type PEXMessage struct {
+	Added       Peers4      `ben:"added,omitempty"`
+	AddedFlags  PEXFlagList `ben:"added.f,omitempty"`
+	Dropped     Peers4      `ben:"dropped,omitempty"`
+	Added6      Peers6      `ben:"added6,omitempty"`
+	Added6Flags PEXFlagList `ben:"added6.f,omitempty"`
+	Dropped6    Peers6      `ben:"dropped6,omitempty"`
+}
+
+// PEXPeer is a peer added by a PEX message.
+type PEXPeer struct {
+	Addr  netip.AddrPort
+	Flags PEXFlags
+}
+
+func (PEXMessage) TryFrom(d Dictionary) (PEXMessage, error) {
+	return castFromDictionaryInto[PEXMessage](d)
+}
+
+func (m PEXMessage) TryInto() (Dictionary, error) {
+	return castFromStructIntoDictionary(m)
+}
+
+// ParsePEXMessage decodes the payload of a ut_pex message.
+func ParsePEXMessage(payload []byte) (PEXMessage, error) {
+	dict, err := Decode[Dictionary](bufio.NewReader(bytes.NewReader(payload)))
+	if err != nil {
+		return PEXMessage{}, err
+	}
+
+	return PEXMessage{}.TryFrom(dict)
+}
+
+// MarshalBinary encodes the message as the payload of a ut_pex message.
+func (m PEXMessage) MarshalBinary() ([]byte, error) {
+	dict, err := m.TryInto()
+	if err != nil {
+		return nil, err
+	}
+
+	return dict.Encode(), nil
+}
+
+// NewPEXMessage splits added and dropped peers by address family,
+// invalid addresses are left out.
+func NewPEXMessage(added []PEXPeer, dropped []netip.AddrPort) PEXMessage {
+	var m PEXMessage
+
+	for _, peer := range added {
+		addr := unmapAddrPort(peer.Addr)
+		if !addr.IsValid() {
+			continue
+		}
+
+		if addr.Addr().Is4() {
+			m.Added = append(m.Added, addr)
+			m.AddedFlags = append(m.AddedFlags, peer.Flags)
+		} else {
+			m.Added6 = append(m.Added6, addr)
+			m.Added6Flags = append(m.Added6Flags, peer.Flags)
+		}
+	}
+
+	for _, peer := range dropped {
+		addr := unmapAddrPort(peer)
+		if !addr.IsValid() {
+			continue
+		}
+
+		if addr.Addr().Is4() {
+			m.Dropped = append(m.Dropped, addr)
+		} else {
+			m.Dropped6 = append(m.Dropped6, addr)
+		}
+	}
+
+	return m
+}
+
+// AddedPeers returns the added peers of both address families with their flags,
+// peers without flags have none set.
+func (m PEXMessage) AddedPeers() []PEXPeer {
+	peers := make([]PEXPeer, 0, len(m.Added)+len(m.Added6))
+
+	for i, addr := range m.Added {
+		peers = append(peers, PEXPeer{Addr: addr, Flags: flagAt(m.AddedFlags, i)})
+	}
+
+	for i, addr := range m.Added6 {
+		peers = append(peers, PEXPeer{Addr: addr, Flags: flagAt(m.Added6Flags, i)})
+	}
+
+	return peers
+}
+
+// DroppedPeers returns the dropped peers of both address families.
+func (m PEXMessage) DroppedPeers() []netip.AddrPort {
+	return slices.Concat([]netip.AddrPort(m.Dropped), m.Dropped6)
+}
+
+// Apply returns peers updated with the changes of m, which is the list of peers known
+// to the receiver of m.
+func (m PEXMessage) Apply(peers []PEXPeer) []PEXPeer {
+	added := m.AddedPeers()
+
+	// added peers replace the previous ones, whose flags may have changed
+	removed := make(map[netip.AddrPort]bool, len(added))
+	for _, peer := range added {
+		removed[unmapAddrPort(peer.Addr)] = true
+	}
+
+	for _, addr := range m.DroppedPeers() {
+		removed[unmapAddrPort(addr)] = true
+	}
+
+	result := make([]PEXPeer, 0, len(peers)+len(added))
+
+	for _, peer := range peers {
+		if !removed[unmapAddrPort(peer.Addr)] {
+			result = append(result, peer)
+		}
+	}
+
+	return append(result, added...)
+}
+
+func flagAt(flags PEXFlagList, i int) PEXFlags {
+	if i < len(flags) {
+		return flags[i]
+	}
+
+	return 0
+}
+
+// DiffPEX returns the message announcing the change from the peers of prev to those of curr,
+// a peer whose flags changed is added again. Peers are sorted and only the first 50 added
+// and 50 dropped ones are sent (BEP 11), the others are left for the next message when prev
+// tracks the messages already sent with PEXMessage.Apply.
+func DiffPEX(prev, curr []PEXPeer) PEXMessage {
+	// invalid addresses are never sent, they must not take the place of valid ones
+	before := make(map[netip.AddrPort]PEXFlags, len(prev))
+	for _, peer := range prev {
+		if addr := unmapAddrPort(peer.Addr); addr.IsValid() {
+			before[addr] = peer.Flags
+		}
+	}
+
+	var (
+		added   []PEXPeer
+		dropped []netip.AddrPort
+		after   = make(map[netip.AddrPort]bool, len(curr))
+	)
+
+	for _, peer := range curr {
+		addr := unmapAddrPort(peer.Addr)
+		if !addr.IsValid() {
+			continue
+		}
+
+		after[addr] = true
+
+		if flags, ok := before[addr]; !ok || flags != peer.Flags {
+			added = append(added, PEXPeer{Addr: addr, Flags: peer.Flags})
+		}
+	}
+
+	for addr := range before {
+		if !after[addr] {
+			dropped = append(dropped, addr)
+		}
+	}
+
+	slices.SortFunc(added, func(a, b PEXPeer) int { return a.Addr.Compare(b.Addr) })
+	slices.SortFunc(dropped, netip.AddrPort.Compare)
+
+	return NewPEXMessage(added[:min(len(added), maxPEXPeers)], dropped[:min(len(dropped), maxPEXPeers)])
+}
+
+func unmapAddrPort(addr netip.AddrPort) netip.AddrPort {
+	return netip.AddrPortFrom(addr.Addr().Unmap(), addr.Port())
+}
+
+func benPeers4StructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	compact, err := l.Bytes()
+	if err != nil {
+		return err
+	}
+
+	peers, err := ParseCompactPeers(compact, compactIPv4Len)
+	if err != nil {
+		return err
+	}
+
+	obj.Set(reflect.ValueOf(Peers4(peers)))
+
+	return nil
+}
+
+func benPEXFlagListStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	raw, err := l.Bytes()
+	if err != nil {
+		return err
+	}
+
+	flags := make(PEXFlagList, len(raw))
+	for i, b := range raw {
+		flags[i] = PEXFlags(b)
+	}
+
+	obj.Set(reflect.ValueOf(flags))
+
+	return nil
+}
+
+func benPeers4StructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	peers, _ := obj.Interface().(Peers4)
+
+	compact, err := CompactPeers(peers, compactIPv4Len)
+
+	return Str(string(compact)), err
+}
+
+func benPEXFlagListStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	flags, _ := obj.Interface().(PEXFlagList)
+
+	raw := make([]byte, len(flags))
+	for i, flag := range flags {
+		raw[i] = byte(flag)
+	}
+
+	return Str(string(raw)), nil
+}
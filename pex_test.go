@@ -0,0 +1,106 @@
+package ben_test
+
+import (
+	"net/netip"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PEX messages", func() {
+	var (
+		peerA = netip.MustParseAddrPort("192.0.2.1:6881")
+		peerB = netip.MustParseAddrPort("192.0.2.2:51413")
+		peerC = netip.MustParseAddrPort("[2001:db8::1]:6881")
+	)
+
+	It("decodes compact peers and their flags", func() {
+		payload := "d5:added12:\xc0\x00\x02\x01\x1a\xe1\xc0\x00\x02\x02\xc8\xd57:added.f2:\x12\x05" +
+			"6:added618:\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01\x1a\xe1" +
+			"7:dropped6:\xc0\x00\x02\x03\x1a\xe1e"
+
+		msg, err := ben.ParsePEXMessage([]byte(payload))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.AddedPeers()).To(Equal([]ben.PEXPeer{
+			{Addr: peerA, Flags: ben.PEXSeed | ben.PEXOutgoing},
+			{Addr: peerB, Flags: ben.PEXEncryption | ben.PEXUTP},
+			{Addr: peerC},
+		}))
+		Expect(msg.DroppedPeers()).To(Equal([]netip.AddrPort{netip.MustParseAddrPort("192.0.2.3:6881")}))
+	})
+
+	It("round-trips messages of both address families", func() {
+		msg := ben.NewPEXMessage(
+			[]ben.PEXPeer{{Addr: peerA, Flags: ben.PEXHolepunch}, {Addr: peerC, Flags: ben.PEXSeed}},
+			[]netip.AddrPort{netip.MustParseAddrPort("[::ffff:192.0.2.2]:51413"), netip.MustParseAddrPort("[2001:db8::2]:1")},
+		)
+		Expect(msg.Dropped).To(Equal(ben.Peers4{peerB}))
+
+		raw, err := msg.MarshalBinary()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(raw)).To(ContainSubstring("7:added.f1:\x08"))
+
+		decoded, err := ben.ParsePEXMessage(raw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded).To(Equal(msg))
+	})
+
+	It("rejects truncated compact peers", func() {
+		_, err := ben.ParsePEXMessage([]byte("d5:added5:\xc0\x00\x02\x01\x1ae"))
+		Expect(err).To(MatchError(ben.ErrInvalidCompactAddr))
+	})
+
+	It("diffs peer snapshots", func() {
+		prev := []ben.PEXPeer{{Addr: peerA}, {Addr: peerB}, {Addr: peerC}}
+		curr := []ben.PEXPeer{{Addr: peerC, Flags: ben.PEXSeed}, {Addr: peerA}, {Addr: netip.MustParseAddrPort("192.0.2.9:1")}}
+
+		msg := ben.DiffPEX(prev, curr)
+		Expect(msg.AddedPeers()).To(Equal([]ben.PEXPeer{
+			{Addr: netip.MustParseAddrPort("192.0.2.9:1")},
+			{Addr: peerC, Flags: ben.PEXSeed},
+		}))
+		Expect(msg.DroppedPeers()).To(Equal([]netip.AddrPort{peerB}))
+
+		Expect(ben.DiffPEX(curr, curr)).To(Equal(ben.PEXMessage{}))
+		Expect(msg.Apply(prev)).To(ConsistOf(curr))
+	})
+
+	It("spreads large changes over several messages", func() {
+		var curr []ben.PEXPeer
+		for i := range 60 {
+			curr = append(curr, ben.PEXPeer{Addr: netip.AddrPortFrom(netip.AddrFrom4([4]byte{192, 0, 2, byte(i)}), 6881)})
+		}
+
+		msg := ben.DiffPEX(nil, curr)
+		Expect(msg.Added).To(HaveLen(50))
+
+		sent := msg.Apply(nil)
+		msg = ben.DiffPEX(sent, curr)
+		Expect(msg.Added).To(HaveLen(10))
+
+		sent = msg.Apply(sent)
+		Expect(sent).To(ConsistOf(curr))
+		Expect(ben.DiffPEX(sent, curr)).To(Equal(ben.PEXMessage{}))
+	})
+
+	It("leaves out invalid addresses", func() {
+		msg := ben.NewPEXMessage([]ben.PEXPeer{{Flags: ben.PEXSeed}}, []netip.AddrPort{{}})
+		Expect(msg).To(Equal(ben.PEXMessage{}))
+
+		// invalid addresses do not count toward the 50 peers of a message
+		curr := make([]ben.PEXPeer, 60, 70)
+		for i := range 10 {
+			curr = append(curr, ben.PEXPeer{Addr: netip.AddrPortFrom(netip.AddrFrom4([4]byte{192, 0, 2, byte(i)}), 6881)})
+		}
+
+		msg = ben.DiffPEX(nil, curr)
+		Expect(msg.Added).To(HaveLen(10))
+		Expect(ben.DiffPEX(msg.Apply(nil), curr)).To(Equal(ben.PEXMessage{}))
+		Expect(ben.DiffPEX(curr[:60], nil)).To(Equal(ben.PEXMessage{}))
+	})
+})
@@ -0,0 +1,124 @@
+package ben
+
+import (
+	"bufio"
+	"bytes"
+	"time"
+)
+
+// qBittorrent keeps its own state in the fastresume file, next to the libtorrent one.
+const (
+	qbtCategory = "qBt-category"
+	qbtTags     = "qBt-tags"
+	qbtSavePath = "qBt-savePath"
+)
+
+// QBittorrentResume is a torrent of the qBittorrent BT_backup directory, made of
+// a `<info-hash>.fastresume` file and a `<info-hash>.torrent` file.
+type QBittorrentResume struct {
+	FastResume
+
+	Torrent Torrent
+}
+
+// ParseQBittorrentResume decodes the fastresume and the torrent files of a torrent,
+// the info dictionary of the torrent file must match the info-hashes of the fastresume file.
+func ParseQBittorrentResume(fastResume, torrent []byte) (QBittorrentResume, error) {
+	resume, err := ParseFastResume(fastResume)
+	if err != nil {
+		return QBittorrentResume{}, err
+	}
+
+	dict, err := Decode[Dictionary](bufio.NewReader(bytes.NewReader(torrent)))
+	if err != nil {
+		return QBittorrentResume{}, err
+	}
+
+	info, ok := dict.Val["info"].(Dictionary)
+	if !ok {
+		return QBittorrentResume{}, ErrInvalidResumeData
+	}
+
+	if err := resume.verifyInfo(info); err != nil {
+		return QBittorrentResume{}, err
+	}
+
+	parsed, err := Torrent{}.TryFrom(dict)
+	if err != nil {
+		return QBittorrentResume{}, err
+	}
+
+	return QBittorrentResume{FastResume: resume, Torrent: parsed}, nil
+}
+
+// Category returns the qBittorrent category, empty when uncategorized.
+func (r QBittorrentResume) Category() string {
+	return r.extraString(qbtCategory)
+}
+
+// Tags returns the qBittorrent tags.
+func (r QBittorrentResume) Tags() []string {
+	list, err := r.extra(qbtTags).List()
+	if err != nil {
+		return nil
+	}
+
+	var tags []string
+
+	for _, elm := range list.Val {
+		if tag, err := elm.String(); err == nil {
+			tags = append(tags, tag.Into())
+		}
+	}
+
+	return tags
+}
+
+// Destination returns the libtorrent save path, or the one of older qBittorrent versions.
+func (r QBittorrentResume) Destination() string {
+	if r.SavePath != "" {
+		return r.SavePath
+	}
+
+	return r.extraString(qbtSavePath)
+}
+
+func (r QBittorrentResume) Progress(info Info) Bitfield {
+	return r.Pieces.Bitfield().truncate(info.PieceCount())
+}
+
+func (r QBittorrentResume) AddedDate() time.Time {
+	return r.AddedTime
+}
+
+// Labels returns the category, when set, followed by the tags.
+func (r QBittorrentResume) Labels() []string {
+	var labels []string
+
+	if category := r.Category(); category != "" {
+		labels = append(labels, category)
+	}
+
+	return append(labels, r.Tags()...)
+}
+
+func (r QBittorrentResume) Ratio() float64 {
+	return ratio(r.TotalUploaded, r.TotalDownloaded)
+}
+
+func (r QBittorrentResume) extra(key string) Element {
+	if elm, ok := r.Extra[key]; ok {
+		return elm
+	}
+
+	return Str("")
+}
+
+func (r QBittorrentResume) extraString(key string) string {
+	str, err := r.extra(key).String()
+	if err != nil {
+		return ""
+	}
+
+	return str.Into()
+}
@@ -0,0 +1,75 @@
+package ben
+
+import "time"
+
+// ResumeData is the state of a torrent saved by a BitTorrent client, as needed to move it
+// to another client.
+type ResumeData interface {
+	// Destination is the directory the torrent is saved in.
+	Destination() string
+	// Progress returns the downloaded pieces of the torrent described by info.
+	Progress(info Info) Bitfield
+	AddedDate() time.Time
+	// Labels are the labels of the torrent, or its category followed by its tags.
+	Labels() []string
+	// Ratio is the uploaded size over the downloaded size, zero when nothing was downloaded.
+	Ratio() float64
+}
+
+var (
+	_ ResumeData = TransmissionResume{}
+	_ ResumeData = QBittorrentResume{}
+)
+
+func ratio(uploaded, downloaded int64) float64 {
+	if downloaded <= 0 {
+		return 0
+	}
+
+	return float64(uploaded) / float64(downloaded)
+}
+
+// fullBitfield returns a bitfield of n pieces, all of them set.
+func fullBitfield(n int) Bitfield {
+	bitfield := NewBitfield(n)
+	for piece := range n {
+		bitfield.Set(piece)
+	}
+
+	return bitfield
+}
+
+// truncate returns a copy of b holding n pieces, pieces beyond b are not set.
+func (b Bitfield) truncate(n int) Bitfield {
+	bitfield := NewBitfield(n)
+	for piece := range n {
+		if b.Has(piece) {
+			bitfield.Set(piece)
+		}
+	}
+
+	return bitfield
+}
+
+// piecesFromBlocks returns the pieces of info whose 16 KiB blocks are all set in blocks,
+// blocks being counted from the start of the torrent content.
+func piecesFromBlocks(blocks Bitfield, info Info) Bitfield {
+	length := info.TotalLength()
+	bitfield := NewBitfield(info.PieceCount())
+
+	for piece := range info.PieceCount() {
+		start := int64(piece) * info.PieceLength
+		end := min(start+info.PieceLength, length)
+		complete := start < end
+
+		for block := start / BlockSize; complete && block*BlockSize < end; block++ {
+			complete = blocks.Has(int(block))
+		}
+
+		if complete {
+			bitfield.Set(piece)
+		}
+	}
+
+	return bitfield
+}
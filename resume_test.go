@@ -0,0 +1,141 @@
+package ben_test
+
+import (
+	"crypto/sha1" //nolint: gosec // mandated by the spec
+	"time"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client resume files", func() {
+	Context("Transmission", func() {
+		const resumeFile = "d13:activity-datei1700000900e10:added-datei1700000000e" +
+			"11:destination10:/downloads9:done-datei1700000600e10:downloadedi1000e" +
+			"6:labelsl5:linux3:isoe9:max-peersi50e4:name7:abc.iso6:pausedi0e" +
+			"8:progressd6:blocks1:\xec12:time-checkedi1700000600ee8:uploadedi2500ee"
+
+		// three pieces of two blocks, the last one ending in the middle of its second block
+		info := ben.Info{PieceLength: 2 * ben.BlockSize, Length: 6*ben.BlockSize - 10000, Pieces: make([]ben.SHA1, 3)}
+
+		It("reads the common fields", func() {
+			resume, err := ben.ParseTransmissionResume([]byte(resumeFile))
+			Expect(err).NotTo(HaveOccurred())
+
+			var data ben.ResumeData = resume
+			Expect(data.Destination()).To(Equal("/downloads"))
+			Expect(data.AddedDate()).To(Equal(time.Unix(1700000000, 0)))
+			Expect(data.Labels()).To(Equal([]string{"linux", "iso"}))
+			Expect(data.Ratio()).To(Equal(2.5))
+			Expect(data.Progress(info)).To(Equal(ben.Bitfield{0xa0}))
+			Expect(resume.Done).To(Equal(time.Unix(1700000600, 0)))
+		})
+
+		It("reads resume files without dates nor labels", func() {
+			resume, err := ben.ParseTransmissionResume([]byte("d11:destination5:/data8:progressd6:blocks4:noneee"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resume.AddedDate()).To(BeZero())
+			Expect(resume.Labels()).To(BeEmpty())
+			Expect(resume.Progress(info)).To(Equal(ben.Bitfield{0}))
+		})
+
+		It("expands completed and empty progress", func() {
+			info := ben.Info{PieceLength: ben.BlockSize, Length: 10 * ben.BlockSize, Pieces: make([]ben.SHA1, 10)}
+
+			resume := ben.TransmissionResume{PieceStates: ben.TransmissionProgress{Blocks: "all"}}
+			Expect(resume.Progress(info)).To(Equal(ben.Bitfield{0xff, 0xc0}))
+
+			resume = ben.TransmissionResume{PieceStates: ben.TransmissionProgress{Have: "all"}}
+			Expect(resume.Progress(info).Count()).To(Equal(10))
+
+			resume = ben.TransmissionResume{PieceStates: ben.TransmissionProgress{Blocks: "none"}}
+			Expect(resume.Progress(info)).To(Equal(ben.Bitfield{0, 0}))
+		})
+
+		It("needs every block of a piece, the partial last block included", func() {
+			resume := ben.TransmissionResume{PieceStates: ben.TransmissionProgress{Blocks: "\xe8"}}
+			Expect(resume.Progress(info)).To(Equal(ben.Bitfield{0x80}))
+		})
+
+		It("falls back to the block bitfield of older versions", func() {
+			resume := ben.TransmissionResume{PieceStates: ben.TransmissionProgress{Bitfield: "\xff"}}
+			Expect(resume.Progress(info)).To(Equal(ben.Bitfield{0xe0}))
+			Expect(resume.Ratio()).To(BeZero())
+		})
+	})
+
+	Context("qBittorrent", func() {
+		const info = "d6:lengthi40000e4:name7:abc.iso12:piece lengthi16384e" +
+			"6:pieces60:aaaaaaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbbbbbcccccccccccccccccccce"
+
+		var (
+			infoHash       = sha1.Sum([]byte(info)) //nolint: gosec // see import
+			torrentFile    = "d8:announce17:http://a/announce4:info" + info + "e"
+			fastResumeFile = "d10:added_timei1700000000e9:info-hash20:" + string(infoHash[:]) +
+				"6:pieces3:\x01\x01\x0012:qBt-category5:linux8:qBt-tagsl3:iso6:stablee" +
+				"9:save_path10:/downloads16:total_downloadedi400e14:total_uploadedi100ee"
+		)
+
+		It("reads the common fields", func() {
+			resume, err := ben.ParseQBittorrentResume([]byte(fastResumeFile), []byte(torrentFile))
+			Expect(err).NotTo(HaveOccurred())
+
+			var data ben.ResumeData = resume
+			Expect(data.Destination()).To(Equal("/downloads"))
+			Expect(data.AddedDate()).To(Equal(time.Unix(1700000000, 0)))
+			Expect(data.Labels()).To(Equal([]string{"linux", "iso", "stable"}))
+			Expect(data.Ratio()).To(Equal(0.25))
+			Expect(data.Progress(resume.Torrent.Info)).To(Equal(ben.Bitfield{0xc0}))
+
+			Expect(resume.Category()).To(Equal("linux"))
+			Expect(resume.Tags()).To(Equal([]string{"iso", "stable"}))
+			Expect(resume.Torrent.Info.Name).To(Equal("abc.iso"))
+		})
+
+		It("falls back to the save path of older versions", func() {
+			resume, err := ben.ParseQBittorrentResume(
+				[]byte("d9:info-hash20:"+string(infoHash[:])+"12:qBt-savePath4:/olde"),
+				[]byte(torrentFile),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resume.Destination()).To(Equal("/old"))
+			Expect(resume.Labels()).To(BeEmpty())
+		})
+
+		It("reads a seeding torrent", func() {
+			resume, err := ben.ParseQBittorrentResume(
+				[]byte("d14:completed_timei1700000600e9:info-hash20:"+string(infoHash[:])+
+					"6:pieces3:\x01\x01\x019:save_path5:/data16:total_downloadedi40000e"+
+					"14:total_uploadedi80000ee"),
+				[]byte(torrentFile),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resume.Progress(resume.Torrent.Info)).To(Equal(ben.Bitfield{0xe0}))
+			Expect(resume.CompletedTime).To(Equal(time.Unix(1700000600, 0)))
+			Expect(resume.Ratio()).To(Equal(2.0))
+		})
+
+		It("rejects a torrent file of another torrent", func() {
+			_, err := ben.ParseQBittorrentResume(
+				[]byte("d9:info-hash20:aaaaaaaaaaaaaaaaaaaa9:save_path5:/datae"),
+				[]byte(torrentFile),
+			)
+			Expect(err).To(MatchError(ben.ErrMetadataHashMismatch))
+		})
+
+		It("rejects a torrent file without info dictionary", func() {
+			_, err := ben.ParseQBittorrentResume([]byte(fastResumeFile), []byte("d8:announce17:http://a/announcee"))
+			Expect(err).To(MatchError(ben.ErrInvalidResumeData))
+		})
+
+		It("fails on a broken torrent file", func() {
+			_, err := ben.ParseQBittorrentResume([]byte(fastResumeFile), []byte("d4:info"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,309 @@
+package ben
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/bits"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// BucketSize is the K of Kademlia, the number of nodes per bucket.
+	BucketSize = 8
+
+	nodeIDBits = 160
+
+	// nodes are questionable after 15 minutes of inactivity and bad after failing
+	// to respond twice in a row, buckets are refreshed after 15 minutes without change (BEP 5).
+	nodeGoodFor     = 15 * time.Minute
+	nodeMaxFailures = 2
+	bucketRefresh   = 15 * time.Minute
+)
+
+type NodeState int
+
+const (
+	NodeGood NodeState = iota
+	NodeQuestionable
+	NodeBad
+)
+
+func (id NodeID) Xor(other NodeID) NodeID {
+	var distance NodeID
+	for i := range id {
+		distance[i] = id[i] ^ other[i]
+	}
+
+	return distance
+}
+
+// commonPrefixLen returns the number of leading bits shared by id and other.
+func (id NodeID) commonPrefixLen(other NodeID) int {
+	distance := id.Xor(other)
+	for i, b := range distance {
+		if b != 0 {
+			return i*8 + bits.LeadingZeros8(b)
+		}
+	}
+
+	return nodeIDBits
+}
+
+// RandomNodeID returns a random node id.
+func RandomNodeID() NodeID {
+	var id NodeID
+	_, _ = rand.Read(id[:])
+
+	return id
+}
+
+type routingEntry struct {
+	NodeInfo
+
+	lastResponse time.Time
+	lastQuery    time.Time
+	failures     int
+}
+
+func (e *routingEntry) state(now time.Time) NodeState {
+	switch {
+	case e.failures >= nodeMaxFailures:
+		return NodeBad
+	case now.Sub(e.lastResponse) < nodeGoodFor || now.Sub(e.lastQuery) < nodeGoodFor:
+		return NodeGood
+	default:
+		return NodeQuestionable
+	}
+}
+
+type bucket struct {
+	entries     []*routingEntry
+	lastChanged time.Time
+}
+
+// RoutingTable is a Kademlia routing table, bucket i holds the nodes sharing
+// exactly i leading bits with the own id. It is safe for concurrent use.
+type RoutingTable struct {
+	mu      sync.Mutex
+	self    NodeID
+	buckets [nodeIDBits]bucket
+	secure  bool
+}
+
+func NewRoutingTable(self NodeID) *RoutingTable {
+	table := &RoutingTable{self: self}
+
+	now := time.Now()
+	for i := range table.buckets {
+		table.buckets[i].lastChanged = now
+	}
+
+	return table
+}
+
+func (t *RoutingTable) bucketFor(id NodeID) *bucket {
+	return &t.buckets[min(t.self.commonPrefixLen(id), nodeIDBits-1)]
+}
+
+func (b *bucket) find(id NodeID) *routingEntry {
+	for _, entry := range b.entries {
+		if entry.ID == id {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// SetSecureIDs makes the table reject nodes whose id does not match their address (BEP 42).
+func (t *RoutingTable) SetSecureIDs(enforce bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.secure = enforce
+}
+
+// Responded records a response from node, adding it to the table when its bucket has room.
+// When the bucket is full of live nodes the least recently seen questionable node is returned,
+// it should be pinged and marked with Failed when it does not respond.
+func (t *RoutingTable) Responded(node NodeInfo) (NodeInfo, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if node.ID == t.self || t.secure && !node.ID.IsSecureFor(node.Addr.Addr()) {
+		return NodeInfo{}, false
+	}
+
+	now := time.Now()
+	b := t.bucketFor(node.ID)
+
+	if entry := b.find(node.ID); entry != nil {
+		entry.Addr = node.Addr
+		entry.lastResponse = now
+		entry.failures = 0
+		b.lastChanged = now
+
+		return NodeInfo{}, false
+	}
+
+	entry := &routingEntry{NodeInfo: node, lastResponse: now}
+
+	if len(b.entries) < BucketSize {
+		b.entries = append(b.entries, entry)
+		b.lastChanged = now
+
+		return NodeInfo{}, false
+	}
+
+	var stale *routingEntry
+
+	for idx, other := range b.entries {
+		switch other.state(now) {
+		case NodeBad:
+			b.entries[idx] = entry
+			b.lastChanged = now
+
+			return NodeInfo{}, false
+
+		case NodeQuestionable:
+			if stale == nil || other.lastResponse.Before(stale.lastResponse) {
+				stale = other
+			}
+
+		case NodeGood:
+		}
+	}
+
+	if stale == nil {
+		return NodeInfo{}, false
+	}
+
+	return stale.NodeInfo, true
+}
+
+// Queried records a query received from a node already in the table.
+func (t *RoutingTable) Queried(id NodeID) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.bucketFor(id).find(id)
+	if entry == nil {
+		return false
+	}
+
+	entry.lastQuery = time.Now()
+
+	return true
+}
+
+// Failed records a query to the node at addr that went unanswered.
+func (t *RoutingTable) Failed(addr netip.AddrPort) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.buckets {
+		for _, entry := range t.buckets[i].entries {
+			if entry.Addr == addr {
+				entry.failures++
+			}
+		}
+	}
+}
+
+// State returns the state of a node, ok is false when it is not in the table.
+func (t *RoutingTable) State(id NodeID) (NodeState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := t.bucketFor(id).find(id)
+	if entry == nil {
+		return NodeBad, false
+	}
+
+	return entry.state(time.Now()), true
+}
+
+// Closest returns up to n nodes closest to target, bad nodes excluded.
+func (t *RoutingTable) Closest(target NodeID, n int) []NodeInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+
+	var nodes []NodeInfo
+
+	for i := range t.buckets {
+		for _, entry := range t.buckets[i].entries {
+			if entry.state(now) != NodeBad {
+				nodes = append(nodes, entry.NodeInfo)
+			}
+		}
+	}
+
+	sortByDistance(nodes, target)
+
+	return nodes[:min(n, len(nodes))]
+}
+
+// Len returns the number of nodes in the table.
+func (t *RoutingTable) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var n int
+	for i := range t.buckets {
+		n += len(t.buckets[i].entries)
+	}
+
+	return n
+}
+
+// RefreshTargets returns a random id in the range of every non-empty bucket
+// that did not change within the refresh interval before now.
+func (t *RoutingTable) RefreshTargets(now time.Time) []NodeID {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var targets []NodeID
+
+	for i := range t.buckets {
+		b := &t.buckets[i]
+		if len(b.entries) == 0 || now.Sub(b.lastChanged) < bucketRefresh {
+			continue
+		}
+
+		targets = append(targets, t.randomIDInBucket(i))
+		b.lastChanged = now
+	}
+
+	return targets
+}
+
+// randomIDInBucket returns a random id sharing exactly prefix leading bits with the own id.
+func (t *RoutingTable) randomIDInBucket(prefix int) NodeID {
+	id := RandomNodeID()
+
+	for bit := range prefix + 1 {
+		mask := byte(0x80) >> (bit % 8)
+		id[bit/8] = id[bit/8]&^mask | t.self[bit/8]&mask
+	}
+
+	id[prefix/8] ^= byte(0x80) >> (prefix % 8)
+
+	return id
+}
+
+func sortByDistance(nodes []NodeInfo, target NodeID) {
+	slices.SortFunc(nodes, func(a, b NodeInfo) int {
+		return compareDistance(a.ID, b.ID, target)
+	})
+}
+
+// compareDistance compares the distances of a and b to target.
+func compareDistance(a, b, target NodeID) int {
+	distA, distB := a.Xor(target), b.Xor(target)
+	return bytes.Compare(distA[:], distB[:])
+}
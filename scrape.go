@@ -0,0 +1,123 @@
+package ben
+
+import "strings"
+
+// ScrapeResponse is the reply of an HTTP tracker to a scrape request.
+type ScrapeResponse struct {
+	FailureReason string       `ben:"failure reason,omitempty"`
+	Files         ScrapeFiles  `ben:"files,omitempty"`
+	Flags         *ScrapeFlags `ben:"flags,omitempty"`
+}
+
+func (ScrapeResponse) TryFrom(d Dictionary) (ScrapeResponse, error) {
+	return castFromDictionaryInto[ScrapeResponse](d)
+}
+
+// TryInto encodes the response, a failure only carries its reason.
+func (r ScrapeResponse) TryInto() (Dictionary, error) {
+	if r.FailureReason != "" {
+		return Dct(map[string]Element{"failure reason": Str(r.FailureReason)}), nil
+	}
+
+	dict, err := castFromStructIntoDictionary(r)
+	if err != nil {
+		return dict, err
+	}
+
+	// `files` is expected even when no torrent matched
+	if _, ok := dict.Val["files"]; !ok {
+		dict.Val["files"] = Dct(map[string]Element{})
+	}
+
+	return dict, nil
+}
+
+// Err returns the failure reason as an error, if any.
+func (r ScrapeResponse) Err() error {
+	if r.FailureReason == "" {
+		return nil
+	}
+
+	return TrackerFailureError{r.FailureReason}
+}
+
+// ScrapeFiles maps an info-hash to its swarm statistics.
+type ScrapeFiles map[InfoHash]ScrapeFile
+
+func (ScrapeFiles) TryFrom(d Dictionary) (ScrapeFiles, error) {
+	files := make(ScrapeFiles, len(d.Val))
+
+	for hash, elm := range d.Val {
+		if len(hash) != len(InfoHash{}) {
+			return files, ErrInvalidHashLength
+		}
+
+		file, err := castFromDictionaryElement[ScrapeFile](elm)
+		if err != nil {
+			return files, err
+		}
+
+		files[InfoHash([]byte(hash))] = file
+	}
+
+	return files, nil
+}
+
+func (f ScrapeFiles) TryInto() (Dictionary, error) {
+	dict := make(map[string]Element, len(f))
+
+	for hash, file := range f {
+		fileDict, err := file.TryInto()
+		if err != nil {
+			return Dct(dict), err
+		}
+
+		dict[string(hash[:])] = fileDict
+	}
+
+	return Dct(dict), nil
+}
+
+type ScrapeFile struct {
+	// Complete is the number of seeders.
+	Complete int64 `ben:"complete"`
+	// Downloaded is the number of times the download was completed.
+	Downloaded int64 `ben:"downloaded"`
+	// Incomplete is the number of leechers.
+	Incomplete int64  `ben:"incomplete"`
+	Name       string `ben:"name,omitempty"`
+}
+
+func (ScrapeFile) TryFrom(d Dictionary) (ScrapeFile, error) {
+	return castFromDictionaryInto[ScrapeFile](d)
+}
+
+func (f ScrapeFile) TryInto() (Dictionary, error) {
+	return castFromStructIntoDictionary(f)
+}
+
+type ScrapeFlags struct {
+	MinRequestInterval int64 `ben:"min_request_interval,omitempty"`
+}
+
+func (ScrapeFlags) TryFrom(d Dictionary) (ScrapeFlags, error) {
+	return castFromDictionaryInto[ScrapeFlags](d)
+}
+
+func (f ScrapeFlags) TryInto() (Dictionary, error) {
+	return castFromStructIntoDictionary(f)
+}
+
+// ScrapeURL derives the scrape URL from an announce URL, by replacing `announce`
+// right after the last slash with `scrape`, as described in BEP 48.
+// The last slash is looked up in the whole URL, query included.
+func ScrapeURL(announce string) (string, error) {
+	const announcePrefix = "announce"
+
+	idx := strings.LastIndex(announce, "/")
+	if idx < 0 || !strings.HasPrefix(announce[idx+1:], announcePrefix) {
+		return "", ErrScrapeNotSupported
+	}
+
+	return announce[:idx+1] + "scrape" + announce[idx+1+len(announcePrefix):], nil
+}
@@ -0,0 +1,86 @@
+package ben_test
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"github.com/fudanchii/ben"
+	"github.com/fudanchii/infr"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+func decodeScrapeResponse(source string) (ben.ScrapeResponse, error) {
+	dict, err := ben.Decode[ben.Dictionary](bufio.NewReader(bytes.NewBufferString(source)))
+	if err != nil {
+		return ben.ScrapeResponse{}, err
+	}
+
+	return infr.TryFrom[ben.Dictionary, ben.ScrapeResponse](dict).TryInto()
+}
+
+var _ = Describe("Tracker scrape response", func() {
+	hashA := ben.InfoHash([]byte(strings.Repeat("a", 20)))
+	hashB := ben.InfoHash([]byte(strings.Repeat("b", 20)))
+
+	It("decodes statistics keyed by info-hash", func() {
+		source := "d5:filesd20:" + strings.Repeat("a", 20) +
+			"d8:completei5e10:downloadedi50e10:incompletei10e4:name3:abce20:" + strings.Repeat("b", 20) +
+			"d8:completei0e10:downloadedi0e10:incompletei1eee5:flagsd20:min_request_intervali3600eee"
+
+		resp, err := decodeScrapeResponse(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Files).To(Equal(ben.ScrapeFiles{
+			hashA: {Complete: 5, Downloaded: 50, Incomplete: 10, Name: "abc"},
+			hashB: {Incomplete: 1},
+		}))
+		Expect(resp.Flags).To(Equal(&ben.ScrapeFlags{MinRequestInterval: 3600}))
+
+		dict, err := resp.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(Equal(source))
+	})
+
+	It("always encodes the files dictionary", func() {
+		dict, err := ben.ScrapeResponse{}.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(Equal("d5:filesdee"))
+	})
+
+	It("rejects keys that are not info-hashes", func() {
+		_, err := decodeScrapeResponse("d5:filesd3:abcd8:completei5eeee")
+		Expect(err).To(MatchError(ben.ErrInvalidHashLength))
+	})
+
+	It("reports the failure reason as an error", func() {
+		resp, err := decodeScrapeResponse("d14:failure reason6:bannede")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Err()).To(MatchError(ben.TrackerFailureError{Reason: "banned"}))
+	})
+
+	DescribeTable("derives the scrape URL",
+		func(announce, scrape string) {
+			Expect(ben.ScrapeURL(announce)).To(Equal(scrape))
+		},
+		Entry(nil, "http://example.com/announce", "http://example.com/scrape"),
+		Entry(nil, "http://example.com/x/announce", "http://example.com/x/scrape"),
+		Entry(nil, "http://example.com/announce.php", "http://example.com/scrape.php"),
+		Entry(nil, "http://example.com/announce?x2%0644", "http://example.com/scrape?x2%0644"),
+		Entry(nil, "udp://example.com:6969/announce", "udp://example.com:6969/scrape"),
+	)
+
+	DescribeTable("refuses URLs without the scrape convention",
+		func(announce string) {
+			_, err := ben.ScrapeURL(announce)
+			Expect(err).To(MatchError(ben.ErrScrapeNotSupported))
+		},
+		Entry(nil, "http://example.com/a"),
+		Entry(nil, "http://example.com/announce?x=2/4"),
+		Entry(nil, "http://example.com/x%064announce"),
+	)
+})
@@ -0,0 +1,64 @@
+package ben
+
+import (
+	"hash/crc32"
+	"net/netip"
+)
+
+// masks of the address bits covered by a secure node id (BEP 42).
+var (
+	secureIDMaskIPv4 = [4]byte{0x03, 0x0f, 0x3f, 0xff}
+	secureIDMaskIPv6 = [8]byte{0x01, 0x03, 0x07, 0x0f, 0x1f, 0x3f, 0x7f, 0xff}
+
+	castagnoli = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// SecureNodeID returns a random node id derived from the external address addr,
+// as required by the DHT security extension (BEP 42).
+func SecureNodeID(addr netip.Addr) NodeID {
+	id := RandomNodeID()
+	prefix := secureIDPrefix(addr, id[len(id)-1]&0x07)
+
+	id[0] = byte(prefix >> 24)
+	id[1] = byte(prefix >> 16)
+	id[2] = byte(prefix>>8)&0xf8 | id[2]&0x07
+
+	return id
+}
+
+// IsSecureFor reports whether id matches the address addr (BEP 42). Local and private
+// addresses are exempt, any id is valid for them.
+func (id NodeID) IsSecureFor(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() {
+		return true
+	}
+
+	prefix := secureIDPrefix(addr, id[len(id)-1]&0x07)
+
+	return id[0] == byte(prefix>>24) &&
+		id[1] == byte(prefix>>16) &&
+		id[2]&0xf8 == byte(prefix>>8)&0xf8
+}
+
+// secureIDPrefix returns the CRC32-C of the masked address, the 21 leading bits of a secure id.
+func secureIDPrefix(addr netip.Addr, r byte) uint32 {
+	var masked []byte
+
+	addr = addr.Unmap()
+	if addr.Is4() {
+		ip := addr.As4()
+		for i, mask := range secureIDMaskIPv4 {
+			masked = append(masked, ip[i]&mask)
+		}
+	} else {
+		ip := addr.As16()
+		for i, mask := range secureIDMaskIPv6 {
+			masked = append(masked, ip[i]&mask)
+		}
+	}
+
+	masked[0] |= r << 5
+
+	return crc32.Checksum(masked, castagnoli)
+}
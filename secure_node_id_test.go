@@ -0,0 +1,73 @@
+package ben_test
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Secure node id", func() {
+	vectors := map[string]string{
+		"124.31.75.21": "5fbfbff10c5d6a4ec8a88e4c6ab4c28b95eee401",
+		"21.75.31.124": "5a3ce9c14e7a08645677bbd1cfe7d8f956d53256",
+		"65.23.51.170": "a5d43220bc8f112a3d426c84764f8c2a1150e616",
+		"84.124.73.14": "1b0321dd1bb1fe518101ceef99462b947a01ff41",
+		"43.213.53.83": "e56f6cbf5b7c4be0237986d5243b87aa6d51305a",
+	}
+
+	It("validates the BEP 42 test vectors", func() {
+		for ip, hexID := range vectors {
+			var id ben.NodeID
+			copy(id[:], mustDecodeHex(hexID))
+
+			Expect(id.IsSecureFor(netip.MustParseAddr(ip))).To(BeTrue(), ip)
+			Expect(id.IsSecureFor(netip.MustParseAddr("1.2.3.4"))).To(BeFalse(), ip)
+		}
+	})
+
+	It("generates ids matching the address", func() {
+		for _, ip := range []string{"124.31.75.21", "2001:db8::1", "::ffff:65.23.51.170"} {
+			addr := netip.MustParseAddr(ip)
+			id := ben.SecureNodeID(addr)
+
+			Expect(id.IsSecureFor(addr)).To(BeTrue(), ip)
+			Expect(ben.SecureNodeID(addr)).NotTo(Equal(id))
+		}
+	})
+
+	It("exempts local addresses", func() {
+		for _, ip := range []string{"127.0.0.1", "10.1.2.3", "192.168.1.1", "169.254.0.1", "fe80::1"} {
+			Expect(ben.RandomNodeID().IsSecureFor(netip.MustParseAddr(ip))).To(BeTrue(), ip)
+		}
+	})
+
+	It("lets routing tables reject insecure ids", func() {
+		addr := netip.MustParseAddrPort("124.31.75.21:6881")
+		table := ben.NewRoutingTable(ben.RandomNodeID())
+		table.SetSecureIDs(true)
+
+		table.Responded(ben.NodeInfo{ID: ben.NodeID{1, 2, 3}, Addr: addr})
+		Expect(table.Len()).To(BeZero())
+
+		table.Responded(ben.NodeInfo{ID: ben.SecureNodeID(addr.Addr()), Addr: addr})
+		Expect(table.Len()).To(Equal(1))
+	})
+
+	It("reports the external address seen by other nodes", func() {
+		nodes, conns := startDHTNetwork(2)
+
+		_, err := nodes[1].Ping(context.Background(), dhtAddr(conns[0]))
+		Expect(err).NotTo(HaveOccurred())
+
+		external, ok := nodes[1].ExternalAddr()
+		Expect(ok).To(BeTrue())
+		Expect(external).To(Equal(dhtAddr(conns[1])))
+	})
+})
@@ -0,0 +1,188 @@
+package ben
+
+import (
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// SwarmPeer is a peer known to a tracker.
+type SwarmPeer struct {
+	ID   PeerID
+	Addr netip.AddrPort
+	Left int64
+}
+
+func (p SwarmPeer) IsSeeder() bool {
+	return p.Left == 0
+}
+
+type swarm struct {
+	peers      map[PeerID]SwarmPeer
+	lastSeen   map[PeerID]time.Time
+	downloaded int64
+}
+
+// defaultMaxSwarms bounds the info-hashes tracked by a store, see Swarms.MaxSwarms.
+const defaultMaxSwarms = 100000
+
+// Swarms is an in-memory store of peers per info-hash, shared by tracker servers.
+// It is safe for concurrent use.
+type Swarms struct {
+	// MaxSwarms bounds the number of info-hashes tracked, announces for other info-hashes
+	// are not recorded while it is reached. Defaults to 100000.
+	MaxSwarms int
+
+	mu      sync.Mutex
+	swarms  map[InfoHash]*swarm
+	peerTTL time.Duration
+}
+
+// NewSwarms creates a store where peers expire when they did not announce within peerTTL.
+func NewSwarms(peerTTL time.Duration) *Swarms {
+	return &Swarms{swarms: make(map[InfoHash]*swarm), peerTTL: peerTTL}
+}
+
+// Announce records peer in the swarm of hash and returns up to numWant other peers,
+// along with the number of seeders and leechers.
+func (s *Swarms) Announce(hash InfoHash, peer SwarmPeer, event AnnounceEvent, numWant int) ([]SwarmPeer, int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	sw, ok := s.swarms[hash]
+	if !ok {
+		// a stopped peer leaves nothing behind
+		if event == EventStopped || !s.hasRoom(now) {
+			return nil, 0, 0
+		}
+
+		sw = &swarm{peers: make(map[PeerID]SwarmPeer), lastSeen: make(map[PeerID]time.Time)}
+		s.swarms[hash] = sw
+	}
+
+	sw.expire(now.Add(-s.peerTTL))
+
+	//nolint: exhaustive // other events only refresh the peer
+	switch event {
+	case EventStopped:
+		delete(sw.peers, peer.ID)
+		delete(sw.lastSeen, peer.ID)
+
+	case EventCompleted:
+		sw.downloaded++
+		fallthrough
+
+	default:
+		sw.peers[peer.ID] = peer
+		sw.lastSeen[peer.ID] = now
+	}
+
+	var (
+		peers                []SwarmPeer
+		complete, incomplete int
+	)
+
+	// map iteration order is random, which spreads peers across requests
+	for id, other := range sw.peers {
+		if other.IsSeeder() {
+			complete++
+		} else {
+			incomplete++
+		}
+
+		if id != peer.ID && len(peers) < numWant && event != EventStopped {
+			peers = append(peers, other)
+		}
+	}
+
+	return peers, complete, incomplete
+}
+
+// Scrape returns statistics for the given info-hashes, or for every swarm when none is given.
+func (s *Swarms) Scrape(hashes ...InfoHash) ScrapeFiles {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadline := time.Now().Add(-s.peerTTL)
+
+	if len(hashes) == 0 {
+		for hash := range s.swarms {
+			hashes = append(hashes, hash)
+		}
+	}
+
+	files := make(ScrapeFiles, len(hashes))
+
+	for _, hash := range hashes {
+		sw, ok := s.swarms[hash]
+		if !ok {
+			continue
+		}
+
+		sw.expire(deadline)
+
+		file := ScrapeFile{Downloaded: sw.downloaded}
+		for _, peer := range sw.peers {
+			if peer.IsSeeder() {
+				file.Complete++
+			} else {
+				file.Incomplete++
+			}
+		}
+
+		files[hash] = file
+	}
+
+	return files
+}
+
+// Expire removes peers that did not announce within the TTL before now,
+// and swarms left without any peer. Swarms are otherwise only pruned when accessed,
+// so this should be called periodically.
+func (s *Swarms) Expire(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(now)
+}
+
+// hasRoom reports whether a new swarm can be tracked, expiring peers when the store is full.
+// s.mu must be held.
+func (s *Swarms) hasRoom(now time.Time) bool {
+	if len(s.swarms) >= s.maxSwarms() {
+		s.expire(now)
+	}
+
+	return len(s.swarms) < s.maxSwarms()
+}
+
+func (s *Swarms) maxSwarms() int {
+	if s.MaxSwarms <= 0 {
+		return defaultMaxSwarms
+	}
+
+	return s.MaxSwarms
+}
+
+func (s *Swarms) expire(now time.Time) {
+	deadline := now.Add(-s.peerTTL)
+
+	for hash, sw := range s.swarms {
+		sw.expire(deadline)
+
+		if len(sw.peers) == 0 {
+			delete(s.swarms, hash)
+		}
+	}
+}
+
+func (sw *swarm) expire(deadline time.Time) {
+	for id, seen := range sw.lastSeen {
+		if seen.Before(deadline) {
+			delete(sw.peers, id)
+			delete(sw.lastSeen, id)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package ben
 
 import (
+	"crypto/sha256"
 	"errors"
 	"reflect"
 	"strings"
@@ -10,40 +11,162 @@ import (
 )
 
 type Torrent struct {
-	Announce     string     `ben:"announce,omitempty"`
-	Info         Info       `ben:"info"`
-	CreatedBy    *string    `ben:"created by,omitempty"`
-	CreationDate *time.Time `ben:"creation date,omitempty"`
-	Encoding     *string    `ben:"encoding,omitempty"`
+	Announce     string       `ben:"announce,omitempty"`
+	AnnounceList AnnounceList `ben:"announce-list,omitempty"`
+	Info         Info         `ben:"info"`
+	CreatedBy    *string      `ben:"created by,omitempty"`
+	CreationDate *time.Time   `ben:"creation date,omitempty"`
+	Encoding     *string      `ben:"encoding,omitempty"`
+	URLList      URLList      `ben:"url-list,omitempty"`
+	HTTPSeeds    []string     `ben:"httpseeds,omitempty"`
+	Nodes        []DHTNode    `ben:"nodes,omitempty"`
+	PieceLayers  PieceLayers  `ben:"piece layers,omitempty"`
+
+	// RawInfo is the bencoded info dictionary as received, such as the metadata assembled from
+	// peers. When set it is written in place of Info, whose fields are then only read from.
+	RawInfo []byte `ben:"-"`
+
+	// Extra holds the entries not written back from the fields above, such as `comment`.
+	Extra map[string]Element `ben:"-"`
 }
 
 func (t Torrent) TryFrom(d Dictionary) (Torrent, error) {
-	return castFromDictionaryInto[Torrent](d)
+	torrent, err := castFromDictionaryInto[Torrent](withValidNodes(d))
+	if err != nil {
+		return torrent, err
+	}
+
+	torrent.Extra, err = leftoverKeys(torrent, d)
+
+	return torrent, err
+}
+
+func (t Torrent) TryInto() (Dictionary, error) {
+	dict, err := castFromStructIntoDictionary(t)
+	if err == nil && t.RawInfo != nil {
+		dict.Val["info"] = rawDictionary{V[[]byte]{t.RawInfo}}
+	}
+
+	return withExtra(dict, t.Extra), err
+}
+
+// rawDictionary is a bencoded dictionary written out as is, even with its keys out of order.
+type rawDictionary struct {
+	V[[]byte]
+}
+
+func (r rawDictionary) Type() ElementType {
+	return DictType
+}
+
+func (r rawDictionary) Encode() []byte {
+	return r.Val
+}
+
+func (r rawDictionary) Dictionary() (Dictionary, error) {
+	dict, _, err := DecodePrefix[Dictionary](r.Val)
+	return dict, err
 }
 
 type Info struct {
 	Name        string `ben:"name"`
-	Length      int64  `ben:"length"`
+	Length      int64  `ben:"length,omitempty"`
 	PieceLength int64  `ben:"piece length,omitempty"`
-	Pieces      []SHA1 `ben:"pieces"`
+	Pieces      []SHA1 `ben:"pieces,omitempty"`
 	Files       []File `ben:"files,omitempty"`
+	Private     bool   `ben:"private,omitempty"`
+	Source      string `ben:"source,omitempty"`
+
+	// BEP 47 attributes of single-file torrents, see File for multi-file torrents.
+	Attr        string   `ben:"attr,omitempty"`
+	SymlinkPath []string `ben:"symlink path,omitempty"`
+	SHA1        SHA1     `ben:"sha1,omitempty"`
+
+	// BEP 52, v2-only torrents have no `pieces`, hybrid torrents have both.
+	MetaVersion int64    `ben:"meta version,omitempty"`
+	FileTree    FileTree `ben:"file tree,omitempty"`
+
+	// Extra holds the entries not written back from the fields above, such as `name.utf-8`,
+	// so that the info-hash survives a round trip.
+	Extra map[string]Element `ben:"-"`
 }
 
 func (Info) TryFrom(d Dictionary) (Info, error) {
-	return castFromDictionaryInto[Info](d)
+	info, err := castFromDictionaryInto[Info](d)
+	if err != nil {
+		return info, err
+	}
+
+	info.Extra, err = leftoverKeys(info, d)
+	if err != nil {
+		return info, err
+	}
+
+	// Private only tells 0 from the rest, the value itself is part of the info-hash
+	if private, ok := d.Val["private"].(Integer); ok && private.Into() != 1 {
+		if info.Extra == nil {
+			info.Extra = make(map[string]Element)
+		}
+
+		info.Extra["private"] = private
+	}
+
+	return info, nil
+}
+
+func (i Info) TryInto() (Dictionary, error) {
+	dict, err := castFromStructIntoDictionary(i)
+	if err != nil {
+		return dict, err
+	}
+
+	dict = withExtra(dict, i.Extra)
+
+	// the `private` kept in Extra is written back as long as Private agrees with it
+	if private, ok := i.Extra["private"].(Integer); ok {
+		switch {
+		case (private.Into() != 0) == i.Private:
+			dict.Val["private"] = private
+		case !i.Private:
+			delete(dict.Val, "private")
+		}
+	}
+
+	return dict, nil
 }
 
 type File struct {
-	Length int64    `ben:"length"`
-	Path   []string `ben:"path"`
+	Length      int64    `ben:"length"`
+	Path        []string `ben:"path"`
+	Attr        string   `ben:"attr,omitempty"`
+	SymlinkPath []string `ben:"symlink path,omitempty"`
+	SHA1        SHA1     `ben:"sha1,omitempty"`
+
+	// Extra holds the entries not written back from the fields above, see Info.
+	Extra map[string]Element `ben:"-"`
 }
 
 func (File) TryFrom(d Dictionary) (File, error) {
-	return castFromDictionaryInto[File](d)
+	file, err := castFromDictionaryInto[File](d)
+	if err != nil {
+		return file, err
+	}
+
+	file.Extra, err = leftoverKeys(file, d)
+
+	return file, err
+}
+
+func (f File) TryInto() (Dictionary, error) {
+	dict, err := castFromStructIntoDictionary(f)
+	return withExtra(dict, f.Extra), err
 }
 
 type SHA1 []byte
 
+// URLList is either a single string or a list of strings in its bencoded form.
+type URLList []string
+
 type valueSetterMap map[reflect.Kind]valueSetterFunc
 
 type valueSetterFunc func(valueSetterMap, reflect.Value, Element) error
@@ -69,6 +192,13 @@ func benSHA1StructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
 	}
 
 	hashes := []byte(lval.Into())
+
+	// a single hash, e.g. the per-file `sha1` from BEP 47
+	if obj.Type() == reflect.TypeFor[SHA1]() {
+		obj.Set(reflect.ValueOf(SHA1(hashes)))
+		return nil
+	}
+
 	hashesCount := len(hashes) / 20 // 160 / 8 bytes = 20
 
 	start := 0
@@ -83,12 +213,100 @@ func benSHA1StructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
 	return nil
 }
 
+func benURLListStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	var urls URLList
+
+	switch l.Type() {
+	case StringType:
+		url, _ := l.String()
+		urls = URLList{url.Into()}
+
+	case ListType:
+		list, _ := l.List()
+		for _, elm := range list.Val {
+			url, err := elm.String()
+			if err != nil {
+				return err
+			}
+
+			urls = append(urls, url.Into())
+		}
+
+	case IntType, DictType:
+		return ErrNotAList
+	}
+
+	obj.Set(reflect.ValueOf(urls))
+
+	return nil
+}
+
+func benDHTNodeStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	node, err := dhtNodeFrom(l)
+	if err != nil {
+		return err
+	}
+
+	obj.Set(reflect.ValueOf(node))
+
+	return nil
+}
+
+func benSHA256StructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	lval, err := l.String()
+	if err != nil {
+		return err
+	}
+
+	if len(lval.Into()) != sha256.Size {
+		return ErrInvalidHashLength
+	}
+
+	obj.Set(reflect.ValueOf(SHA256([]byte(lval.Into()))))
+
+	return nil
+}
+
 func setStructValue() map[string]valueSetterFunc {
 	return map[string]valueSetterFunc{
-		"time.Time":                     timeTimeStructSetter,
-		"github.com/fudanchii/ben.Info": setterFor[Info],
-		"github.com/fudanchii/ben.File": setterFor[File],
-		"github.com/fudanchii/ben.SHA1": benSHA1StructSetter,
+		"time.Time":                        timeTimeStructSetter,
+		"github.com/fudanchii/ben.Info":    setterFor[Info],
+		"github.com/fudanchii/ben.File":    setterFor[File],
+		"github.com/fudanchii/ben.SHA1":    benSHA1StructSetter,
+		"github.com/fudanchii/ben.URLList": benURLListStructSetter,
+		"github.com/fudanchii/ben.DHTNode": benDHTNodeStructSetter,
+		"github.com/fudanchii/ben.SHA256":  benSHA256StructSetter,
+
+		"github.com/fudanchii/ben.FileTree":    setterFor[FileTree],
+		"github.com/fudanchii/ben.PieceLayers": setterFor[PieceLayers],
+
+		"github.com/fudanchii/ben.Peers":  benPeersStructSetter,
+		"github.com/fudanchii/ben.Peers6": benPeers6StructSetter,
+
+		"github.com/fudanchii/ben.ScrapeFiles": setterFor[ScrapeFiles],
+		"github.com/fudanchii/ben.ScrapeFlags": setterFor[ScrapeFlags],
+
+		"net/netip.AddrPort":                     netipAddrPortStructSetter,
+		"net/netip.Addr":                         netipAddrStructSetter,
+		"github.com/fudanchii/ben.NodeID":        benByteArrayStructSetter,
+		"github.com/fudanchii/ben.InfoHash":      benByteArrayStructSetter,
+		"github.com/fudanchii/ben.CompactNodes":  benCompactNodesStructSetter,
+		"github.com/fudanchii/ben.CompactNodes6": benCompactNodesStructSetter,
+		"github.com/fudanchii/ben.PeerValues":    benPeerValuesStructSetter,
+		"github.com/fudanchii/ben.KRPCArgs":      setterFor[KRPCArgs],
+		"github.com/fudanchii/ben.KRPCReturn":    setterFor[KRPCReturn],
+		"github.com/fudanchii/ben.KRPCError":     benKRPCErrorStructSetter,
+		"github.com/fudanchii/ben.Element":       benElementStructSetter,
+		"github.com/fudanchii/ben.PublicKey":     benByteArrayStructSetter,
+		"github.com/fudanchii/ben.Signature":     benByteArrayStructSetter,
+		"github.com/fudanchii/ben.BloomFilter":   benByteArrayStructSetter,
+		"github.com/fudanchii/ben.InfoHashes":    benInfoHashesStructSetter,
+
+		"github.com/fudanchii/ben.ExtensionIDs": setterFor[ExtensionIDs],
+		"github.com/fudanchii/ben.Peers4":       benPeers4StructSetter,
+		"github.com/fudanchii/ben.PEXFlagList":  benPEXFlagListStructSetter,
+
+		"github.com/fudanchii/ben.TransmissionProgress": setterFor[TransmissionProgress],
 	}
 }
 
@@ -107,6 +325,21 @@ func setValueForInt64(_ valueSetterMap, obj reflect.Value, l Element) error {
 	return nil
 }
 
+func setValueForBool(_ valueSetterMap, obj reflect.Value, l Element) error {
+	if l == nil {
+		l = Int(0)
+	}
+
+	val, err := l.Integer()
+	if err != nil {
+		return err
+	}
+
+	obj.SetBool(val.Into() != 0)
+
+	return nil
+}
+
 func setValueForString(_ valueSetterMap, obj reflect.Value, l Element) error {
 	if l == nil {
 		l = Str("")
@@ -167,12 +400,23 @@ func setValueForSlice(setter valueSetterMap, obj reflect.Value, l Element) error
 	switch elemType {
 	// []byte
 	case reflect.Uint8:
-		return setter[reflect.String](setter, obj, l)
+		if l == nil {
+			l = Str("")
+		}
+
+		val, err := l.String()
+		if err != nil {
+			return err
+		}
+
+		obj.SetBytes([]byte(val.Into()))
+
+		return nil
 
 	case reflect.Invalid:
 		return errors.New("ben/list: unexpected invalid type for list element")
 
-	// []SHA1 is [][]byte
+	// []SHA1 is [][]byte, while [][]string is a plain nested list
 	case reflect.Slice:
 		fqTypeName := fullyQualifiedTypeName(obj.Type().Elem())
 
@@ -181,22 +425,33 @@ func setValueForSlice(setter valueSetterMap, obj reflect.Value, l Element) error
 			return setterFunc(setter, obj, l)
 		}
 
-		return errTypeNotSupported
+		return setValueForSliceElements(setter, obj, l)
 
 	default:
-		list, err := l.List()
-		if err != nil {
-			return err
-		}
+		return setValueForSliceElements(setter, obj, l)
+	}
+}
 
-		lvLen := len(list.Val)
-		obj.Set(reflect.MakeSlice(obj.Type(), lvLen, lvLen))
+func setValueForSliceElements(setter valueSetterMap, obj reflect.Value, l Element) error {
+	elemType := obj.Type().Elem().Kind()
 
-		for idx := range list.Val {
-			setterErr := setter[elemType](setter, obj.Index(idx), list.Val[idx])
-			if setterErr != nil {
-				return setterErr
-			}
+	setterFunc, ok := setter[elemType]
+	if !ok {
+		return errTypeNotSupported
+	}
+
+	list, err := l.List()
+	if err != nil {
+		return err
+	}
+
+	lvLen := len(list.Val)
+	obj.Set(reflect.MakeSlice(obj.Type(), lvLen, lvLen))
+
+	for idx := range list.Val {
+		setterErr := setterFunc(setter, obj.Index(idx), list.Val[idx])
+		if setterErr != nil {
+			return setterErr
 		}
 	}
 
@@ -207,6 +462,7 @@ func setValue() valueSetterMap {
 	//nolint: exhaustive // no need to cover all types
 	return valueSetterMap{
 		reflect.Int64:   setValueForInt64,
+		reflect.Bool:    setValueForBool,
 		reflect.String:  setValueForString,
 		reflect.Struct:  setValueForStruct,
 		reflect.Pointer: setValueForPointer,
@@ -245,7 +501,7 @@ func castFromDictionaryInto[T infr.TryFromType[Dictionary, T]](dict Dictionary)
 		field := objType.Field(i)
 		fieldVal := objStruct.Field(i)
 
-		if !field.IsExported() {
+		if !field.IsExported() || field.Tag.Get("ben") == "-" {
 			continue
 		}
 
@@ -274,6 +530,283 @@ func castFromDictionaryInto[T infr.TryFromType[Dictionary, T]](dict Dictionary)
 	return t, nil
 }
 
+type valueGetterMap map[reflect.Kind]valueGetterFunc
+
+// valueGetterFunc returns nil Element when there is nothing to encode.
+type valueGetterFunc func(valueGetterMap, reflect.Value) (Element, error)
+
+func timeTimeStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	timeVal, _ := obj.Interface().(time.Time)
+	return Int(timeVal.Unix()), nil
+}
+
+func benSHA1StructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	var hashes []byte
+
+	if obj.Type() == reflect.TypeFor[SHA1]() {
+		return Str(string(obj.Bytes())), nil
+	}
+
+	for i := range obj.Len() {
+		hashes = append(hashes, obj.Index(i).Bytes()...)
+	}
+
+	return Str(string(hashes)), nil
+}
+
+func benURLListStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	urls, _ := obj.Interface().(URLList)
+
+	// a single url is written as a plain string, as most torrent makers do
+	if len(urls) == 1 {
+		return Str(urls[0]), nil
+	}
+
+	list := make([]Element, 0, len(urls))
+	for _, url := range urls {
+		list = append(list, Str(url))
+	}
+
+	return Lst(list), nil
+}
+
+func benDHTNodeStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	node, _ := obj.Interface().(DHTNode)
+	return Lst([]Element{Str(node.Host), Int(int64(node.Port))}), nil
+}
+
+func benSHA256StructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	hash, _ := obj.Interface().(SHA256)
+	return Str(string(hash[:])), nil
+}
+
+func getStructValue() map[string]valueGetterFunc {
+	return map[string]valueGetterFunc{
+		"time.Time":                        timeTimeStructGetter,
+		"github.com/fudanchii/ben.Info":    getterFor[Info],
+		"github.com/fudanchii/ben.File":    getterFor[File],
+		"github.com/fudanchii/ben.SHA1":    benSHA1StructGetter,
+		"github.com/fudanchii/ben.URLList": benURLListStructGetter,
+		"github.com/fudanchii/ben.DHTNode": benDHTNodeStructGetter,
+		"github.com/fudanchii/ben.SHA256":  benSHA256StructGetter,
+
+		"github.com/fudanchii/ben.FileTree":    getterFor[FileTree],
+		"github.com/fudanchii/ben.PieceLayers": getterFor[PieceLayers],
+
+		"github.com/fudanchii/ben.Peers":  benPeersStructGetter,
+		"github.com/fudanchii/ben.Peers6": benPeers6StructGetter,
+
+		"github.com/fudanchii/ben.ScrapeFiles": getterFor[ScrapeFiles],
+		"github.com/fudanchii/ben.ScrapeFlags": getterFor[ScrapeFlags],
+
+		"net/netip.AddrPort":                     netipAddrPortStructGetter,
+		"net/netip.Addr":                         netipAddrStructGetter,
+		"github.com/fudanchii/ben.NodeID":        benByteArrayStructGetter,
+		"github.com/fudanchii/ben.InfoHash":      benByteArrayStructGetter,
+		"github.com/fudanchii/ben.CompactNodes":  benCompactNodesStructGetter,
+		"github.com/fudanchii/ben.CompactNodes6": benCompactNodesStructGetter,
+		"github.com/fudanchii/ben.PeerValues":    benPeerValuesStructGetter,
+		"github.com/fudanchii/ben.KRPCArgs":      getterFor[KRPCArgs],
+		"github.com/fudanchii/ben.KRPCReturn":    getterFor[KRPCReturn],
+		"github.com/fudanchii/ben.KRPCError":     benKRPCErrorStructGetter,
+		"github.com/fudanchii/ben.Element":       benElementStructGetter,
+		"github.com/fudanchii/ben.PublicKey":     benByteArrayStructGetter,
+		"github.com/fudanchii/ben.Signature":     benByteArrayStructGetter,
+		"github.com/fudanchii/ben.BloomFilter":   benByteArrayStructGetter,
+		"github.com/fudanchii/ben.InfoHashes":    benInfoHashesStructGetter,
+
+		"github.com/fudanchii/ben.ExtensionIDs": getterFor[ExtensionIDs],
+		"github.com/fudanchii/ben.Peers4":       benPeers4StructGetter,
+		"github.com/fudanchii/ben.PEXFlagList":  benPEXFlagListStructGetter,
+
+		"github.com/fudanchii/ben.TransmissionProgress": getterFor[TransmissionProgress],
+	}
+}
+
+func getValueForInt64(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	return Int(obj.Int()), nil
+}
+
+func getValueForBool(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	if obj.Bool() {
+		return Int(1), nil
+	}
+
+	return Int(0), nil
+}
+
+func getValueForString(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	return Str(obj.String()), nil
+}
+
+func getValueForStruct(getter valueGetterMap, obj reflect.Value) (Element, error) {
+	fqTypeName := fullyQualifiedTypeName(obj.Type())
+
+	structValueGetter, ok := getStructValue()[fqTypeName]
+	if !ok {
+		return nil, errTypeNotSupported
+	}
+
+	return structValueGetter(getter, obj)
+}
+
+func getValueForPointer(getter valueGetterMap, obj reflect.Value) (Element, error) {
+	if obj.IsNil() {
+		return nil, nil
+	}
+
+	fqTypeName := fullyQualifiedTypeName(obj.Type().Elem())
+	getterFunc, ok := getStructValue()[fqTypeName]
+	if !ok {
+		getterFunc, ok = getter[obj.Type().Elem().Kind()]
+		if !ok {
+			return nil, errTypeNotSupported
+		}
+	}
+
+	return getterFunc(getter, obj.Elem())
+}
+
+func getValueForSlice(getter valueGetterMap, obj reflect.Value) (Element, error) {
+	elemType := obj.Type().Elem()
+
+	//nolint: exhaustive // already covered by default hand
+	switch elemType.Kind() {
+	// []byte
+	case reflect.Uint8:
+		return Str(string(obj.Bytes())), nil
+
+	// []SHA1 is [][]byte, while [][]string is a plain nested list
+	case reflect.Slice:
+		getterFunc, ok := getStructValue()[fullyQualifiedTypeName(elemType)]
+		if ok {
+			return getterFunc(getter, obj)
+		}
+	}
+
+	getterFunc, ok := getter[elemType.Kind()]
+	if !ok {
+		return nil, errTypeNotSupported
+	}
+
+	list := make([]Element, 0, obj.Len())
+
+	for idx := range obj.Len() {
+		elm, err := getterFunc(getter, obj.Index(idx))
+		if err != nil {
+			return nil, err
+		}
+
+		if elm != nil {
+			list = append(list, elm)
+		}
+	}
+
+	return Lst(list), nil
+}
+
+func getValue() valueGetterMap {
+	//nolint: exhaustive // no need to cover all types
+	return valueGetterMap{
+		reflect.Int64:   getValueForInt64,
+		reflect.Bool:    getValueForBool,
+		reflect.String:  getValueForString,
+		reflect.Struct:  getValueForStruct,
+		reflect.Pointer: getValueForPointer,
+		reflect.Slice:   getValueForSlice,
+	}
+}
+
+func getterFor[T infr.TryIntoType[Dictionary]](_ valueGetterMap, obj reflect.Value) (Element, error) {
+	fieldObj, ok := obj.Interface().(T)
+	if !ok {
+		return nil, errTypeNotSupported
+	}
+
+	return fieldObj.TryInto()
+}
+
+func castFromStructIntoDictionary[T any](t T) (Dictionary, error) {
+	dict := make(map[string]Element)
+
+	objType := reflect.TypeOf(t)
+	objStruct := reflect.ValueOf(t)
+
+	numField := objType.NumField()
+
+	for i := range numField {
+		field := objType.Field(i)
+		fieldVal := objStruct.Field(i)
+
+		if !field.IsExported() || field.Tag.Get("ben") == "-" {
+			continue
+		}
+
+		fqTypeName := fullyQualifiedTypeName(field.Type)
+		getterFunc, ok := getStructValue()[fqTypeName]
+
+		if !ok {
+			getterFunc, ok = getValue()[fieldVal.Kind()]
+			if !ok {
+				return Dct(dict), errTypeNotSupported
+			}
+		}
+
+		tag := strings.SplitN(field.Tag.Get("ben"), ",", 2)
+
+		if fieldVal.IsZero() && len(tag) == 2 && tag[1] == "omitempty" {
+			continue
+		}
+
+		val, err := getterFunc(getValue(), fieldVal)
+		if err != nil {
+			return Dct(dict), err
+		}
+
+		if val != nil {
+			dict[tag[0]] = val
+		}
+	}
+
+	return Dct(dict), nil
+}
+
+// leftoverKeys returns the entries of dict missing once t, decoded from it, is encoded back.
+// They are either not mapped to any field of T, or omitted as empty, and nil when there is none.
+func leftoverKeys[T any](t T, dict Dictionary) (map[string]Element, error) {
+	encoded, err := castFromStructIntoDictionary(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var leftover map[string]Element
+
+	for key, val := range dict.Val {
+		if _, ok := encoded.Val[key]; ok {
+			continue
+		}
+
+		if leftover == nil {
+			leftover = make(map[string]Element)
+		}
+
+		leftover[key] = val
+	}
+
+	return leftover, nil
+}
+
+// withExtra adds the entries of extra to dict, the ones already in dict take precedence.
+func withExtra(dict Dictionary, extra map[string]Element) Dictionary {
+	for key, val := range extra {
+		if _, ok := dict.Val[key]; !ok {
+			dict.Val[key] = val
+		}
+	}
+
+	return dict
+}
+
 func fullyQualifiedTypeName(t reflect.Type) string {
 	return t.PkgPath() + "." + t.Name()
 }
@@ -0,0 +1,241 @@
+package ben
+
+import (
+	"math"
+	"net"
+	"net/netip"
+	"reflect"
+	"strconv"
+
+	"github.com/fudanchii/infr"
+)
+
+// AnnounceResponse is the reply of an HTTP tracker to an announce request.
+type AnnounceResponse struct {
+	FailureReason  string `ben:"failure reason,omitempty"`
+	WarningMessage string `ben:"warning message,omitempty"`
+	Interval       int64  `ben:"interval"`
+	MinInterval    int64  `ben:"min interval,omitempty"`
+	TrackerID      string `ben:"tracker id,omitempty"`
+	Complete       int64  `ben:"complete"`
+	Incomplete     int64  `ben:"incomplete"`
+	Peers          Peers  `ben:"peers,omitempty"`
+	Peers6         Peers6 `ben:"peers6,omitempty"`
+
+	// PeerHosts are the peers of a non-compact reply given by host name rather than
+	// by address, as `host:port` to be resolved by the caller.
+	PeerHosts []string `ben:"-"`
+}
+
+func (AnnounceResponse) TryFrom(d Dictionary) (AnnounceResponse, error) {
+	resp, err := castFromDictionaryInto[AnnounceResponse](d)
+	if err != nil {
+		return resp, err
+	}
+
+	// compact peers have no host name
+	peers, ok := d.Val["peers"]
+	if !ok || peers.Type() != ListType {
+		return resp, nil
+	}
+
+	list, _ := peers.List()
+
+	for _, elm := range list.Val {
+		peer, err := castFromDictionaryElement[peerDict](elm)
+		if err != nil {
+			return resp, err
+		}
+
+		if _, err := netip.ParseAddr(peer.IP); err != nil {
+			resp.PeerHosts = append(resp.PeerHosts, net.JoinHostPort(peer.IP, strconv.FormatInt(peer.Port, 10)))
+		}
+	}
+
+	return resp, nil
+}
+
+// TryInto encodes the response, a failure only carries its reason.
+// Peers are written as a list of dictionaries when there are PeerHosts.
+func (r AnnounceResponse) TryInto() (Dictionary, error) {
+	if r.FailureReason != "" {
+		return Dct(map[string]Element{"failure reason": Str(r.FailureReason)}), nil
+	}
+
+	dict, err := castFromStructIntoDictionary(r)
+	if err != nil || len(r.PeerHosts) == 0 {
+		return dict, err
+	}
+
+	list, err := r.Peers.List()
+	if err != nil {
+		return dict, err
+	}
+
+	for _, hostPort := range r.PeerHosts {
+		host, port, err := net.SplitHostPort(hostPort)
+		if err != nil {
+			return dict, err
+		}
+
+		portNum, err := strconv.ParseUint(port, 10, 16)
+		if err != nil || portNum == 0 {
+			return dict, ErrInvalidPeerPort
+		}
+
+		peer, err := castFromStructIntoDictionary(peerDict{IP: host, Port: int64(portNum)})
+		if err != nil {
+			return dict, err
+		}
+
+		list.Val = append(list.Val, peer)
+	}
+
+	dict.Val["peers"] = list
+
+	return dict, nil
+}
+
+// Err returns the failure reason as an error, if any.
+func (r AnnounceResponse) Err() error {
+	if r.FailureReason == "" {
+		return nil
+	}
+
+	return TrackerFailureError{r.FailureReason}
+}
+
+// AllPeers returns peers from both `peers` and `peers6`.
+func (r AnnounceResponse) AllPeers() []netip.AddrPort {
+	return append(append([]netip.AddrPort(nil), r.Peers...), r.Peers6...)
+}
+
+// Peers is either a compact string of 6-byte entries (BEP 23)
+// or a list of dictionaries with `ip` and `port`.
+type Peers []netip.AddrPort
+
+// Peers6 is a compact string of 18-byte entries (BEP 7).
+type Peers6 []netip.AddrPort
+
+func benPeersStructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	var peers Peers
+
+	switch l.Type() {
+	case StringType:
+		compact, _ := l.Bytes()
+
+		parsed, err := ParseCompactPeers(compact, compactIPv4Len)
+		if err != nil {
+			return err
+		}
+
+		peers = parsed
+
+	case ListType:
+		list, _ := l.List()
+		for _, elm := range list.Val {
+			peer, err := castFromDictionaryElement[peerDict](elm)
+			if err != nil {
+				return err
+			}
+
+			if peer.Port <= 0 || peer.Port > math.MaxUint16 {
+				return ErrInvalidPeerPort
+			}
+
+			// peers given as a host name are kept in AnnounceResponse.PeerHosts
+			addr, err := netip.ParseAddr(peer.IP)
+			if err != nil {
+				continue
+			}
+
+			peers = append(peers, netip.AddrPortFrom(addr, uint16(peer.Port))) //nolint: gosec // checked above
+		}
+
+	case IntType, DictType:
+		return ErrNotAList
+	}
+
+	obj.Set(reflect.ValueOf(peers))
+
+	return nil
+}
+
+func benPeers6StructSetter(_ valueSetterMap, obj reflect.Value, l Element) error {
+	compact, err := l.Bytes()
+	if err != nil {
+		return err
+	}
+
+	peers, err := ParseCompactPeers(compact, compactIPv6Len)
+	if err != nil {
+		return err
+	}
+
+	obj.Set(reflect.ValueOf(Peers6(peers)))
+
+	return nil
+}
+
+// benPeersStructGetter writes IPv4 peers in compact form, and falls back to
+// a list of dictionaries when there is an IPv6 peer.
+func benPeersStructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	peers, _ := obj.Interface().(Peers)
+
+	for _, peer := range peers {
+		if peer.Addr().Is6() && !peer.Addr().Is4In6() {
+			return peers.List()
+		}
+	}
+
+	compact, err := CompactPeers(peers, compactIPv4Len)
+
+	return Str(string(compact)), err
+}
+
+func benPeers6StructGetter(_ valueGetterMap, obj reflect.Value) (Element, error) {
+	peers, _ := obj.Interface().(Peers6)
+
+	compact, err := CompactPeers(peers, compactIPv6Len)
+
+	return Str(string(compact)), err
+}
+
+// List returns peers as a non-compact list of dictionaries.
+func (p Peers) List() (List, error) {
+	list := make([]Element, 0, len(p))
+
+	for _, peer := range p {
+		dict, err := castFromStructIntoDictionary(peerDict{
+			IP:   peer.Addr().Unmap().String(),
+			Port: int64(peer.Port()),
+		})
+		if err != nil {
+			return Lst(list), err
+		}
+
+		list = append(list, dict)
+	}
+
+	return Lst(list), nil
+}
+
+type peerDict struct {
+	PeerID string `ben:"peer id,omitempty"`
+	IP     string `ben:"ip"`
+	Port   int64  `ben:"port"`
+}
+
+func (peerDict) TryFrom(d Dictionary) (peerDict, error) {
+	return castFromDictionaryInto[peerDict](d)
+}
+
+func castFromDictionaryElement[T infr.TryFromType[Dictionary, T]](e Element) (T, error) {
+	dict, err := e.Dictionary()
+	if err != nil {
+		var t T
+		return t, err
+	}
+
+	return infr.TryFrom[Dictionary, T](dict).TryInto()
+}
@@ -0,0 +1,225 @@
+package ben
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxTrackerResponseSize bounds the body read from a tracker, the full scrape of a large
+// tracker is the biggest reply expected.
+const maxTrackerResponseSize = 16 * 1024 * 1024
+
+type PeerID [20]byte
+
+type AnnounceEvent string
+
+const (
+	EventNone      AnnounceEvent = ""
+	EventStarted   AnnounceEvent = "started"
+	EventStopped   AnnounceEvent = "stopped"
+	EventCompleted AnnounceEvent = "completed"
+	// EventPaused is sent by partial seeds, peers that stopped downloading (BEP 21).
+	EventPaused AnnounceEvent = "paused"
+)
+
+// AnnounceRequest holds the parameters of an announce to a tracker.
+type AnnounceRequest struct {
+	InfoHash   InfoHash
+	PeerID     PeerID
+	Port       uint16
+	Uploaded   int64
+	Downloaded int64
+	Left       int64
+	Event      AnnounceEvent
+	Compact    bool
+	// NumWant is only sent when positive, trackers pick their own default otherwise.
+	NumWant   int
+	Key       string
+	TrackerID string
+}
+
+// Query returns the announce query string, binary values are percent-encoded byte by byte.
+func (r AnnounceRequest) Query() string {
+	var query strings.Builder
+
+	query.WriteString("info_hash=" + escapeBytes(r.InfoHash[:]))
+	query.WriteString("&peer_id=" + escapeBytes(r.PeerID[:]))
+	query.WriteString("&port=" + strconv.Itoa(int(r.Port)))
+	query.WriteString("&uploaded=" + strconv.FormatInt(r.Uploaded, 10))
+	query.WriteString("&downloaded=" + strconv.FormatInt(r.Downloaded, 10))
+	query.WriteString("&left=" + strconv.FormatInt(r.Left, 10))
+
+	if r.Compact {
+		query.WriteString("&compact=1")
+	} else {
+		query.WriteString("&compact=0")
+	}
+
+	if r.Event != EventNone {
+		query.WriteString("&event=" + escapeBytes([]byte(r.Event)))
+	}
+
+	if r.NumWant > 0 {
+		query.WriteString("&numwant=" + strconv.Itoa(r.NumWant))
+	}
+
+	if r.Key != "" {
+		query.WriteString("&key=" + escapeBytes([]byte(r.Key)))
+	}
+
+	if r.TrackerID != "" {
+		query.WriteString("&trackerid=" + escapeBytes([]byte(r.TrackerID)))
+	}
+
+	return query.String()
+}
+
+// URL appends the announce query to the tracker announce URL.
+func (r AnnounceRequest) URL(announce string) string {
+	return appendQuery(announce, r.Query())
+}
+
+// ScrapeRequestURL returns the scrape URL of a tracker for the given info-hashes.
+func ScrapeRequestURL(announce string, hashes ...InfoHash) (string, error) {
+	scrape, err := ScrapeURL(announce)
+	if err != nil {
+		return "", err
+	}
+
+	params := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		params = append(params, "info_hash="+escapeBytes(hash[:]))
+	}
+
+	return appendQuery(scrape, strings.Join(params, "&")), nil
+}
+
+func appendQuery(base, query string) string {
+	if query == "" {
+		return base
+	}
+
+	if strings.Contains(base, "?") {
+		return base + "&" + query
+	}
+
+	return base + "?" + query
+}
+
+// escapeBytes percent-encodes everything except RFC 3986 unreserved characters.
+// url.QueryEscape is not used since it turns spaces into `+`.
+func escapeBytes(b []byte) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var escaped strings.Builder
+
+	for _, c := range b {
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			escaped.WriteByte(c)
+			continue
+		}
+
+		escaped.WriteByte('%')
+		escaped.WriteByte(hexDigits[c>>4])
+		escaped.WriteByte(hexDigits[c&0x0f])
+	}
+
+	return escaped.String()
+}
+
+// HTTPStatusError is returned when a tracker replies with a non-200 status
+// and a body that is not bencoded.
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (err HTTPStatusError) Error() string {
+	return fmt.Sprintf("tracker returned HTTP status %d", err.StatusCode)
+}
+
+// TrackerClient talks to HTTP trackers.
+type TrackerClient struct {
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	UserAgent  string
+}
+
+// Announce sends req to the tracker, a `failure reason` is returned as TrackerFailureError
+// along with the decoded response.
+func (c *TrackerClient) Announce(ctx context.Context, announce string, req AnnounceRequest) (AnnounceResponse, error) {
+	var resp AnnounceResponse
+
+	dict, err := c.get(ctx, req.URL(announce))
+	if err != nil {
+		return resp, err
+	}
+
+	resp, err = resp.TryFrom(dict)
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, resp.Err()
+}
+
+// Scrape asks the tracker for statistics of the given info-hashes,
+// or of every torrent it tracks when no hash is given.
+func (c *TrackerClient) Scrape(ctx context.Context, announce string, hashes ...InfoHash) (ScrapeResponse, error) {
+	var resp ScrapeResponse
+
+	scrapeURL, err := ScrapeRequestURL(announce, hashes...)
+	if err != nil {
+		return resp, err
+	}
+
+	dict, err := c.get(ctx, scrapeURL)
+	if err != nil {
+		return resp, err
+	}
+
+	resp, err = resp.TryFrom(dict)
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, resp.Err()
+}
+
+func (c *TrackerClient) get(ctx context.Context, url string) (Dictionary, error) {
+	var dict Dictionary
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return dict, err
+	}
+
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	httpResp, err := httpClient.Do(req)
+	if err != nil {
+		return dict, err
+	}
+
+	defer func() { _ = httpResp.Body.Close() }()
+
+	// trackers may send a bencoded failure along with an error status
+	dict, err = Decode[Dictionary](bufio.NewReader(io.LimitReader(httpResp.Body, maxTrackerResponseSize)))
+	if err != nil && httpResp.StatusCode != http.StatusOK {
+		return dict, HTTPStatusError{httpResp.StatusCode}
+	}
+
+	return dict, err
+}
@@ -0,0 +1,121 @@
+package ben_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTP tracker client", func() {
+	var (
+		server  *httptest.Server
+		lastReq *http.Request
+		status  int
+		body    string
+		client  ben.TrackerClient
+		req     ben.AnnounceRequest
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lastReq = r
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+
+		client = ben.TrackerClient{HTTPClient: server.Client(), UserAgent: "ben/test"}
+
+		req = ben.AnnounceRequest{
+			InfoHash:   ben.InfoHash([]byte("\x00\x01 +/?&=~abcdefghijk")),
+			PeerID:     ben.PeerID([]byte("-BN0001-\xff\xfe0123456789")),
+			Port:       6881,
+			Uploaded:   1,
+			Downloaded: 2,
+			Left:       3,
+			Event:      ben.EventStarted,
+			Compact:    true,
+			NumWant:    50,
+			Key:        "a1b2",
+			TrackerID:  "trk id",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("builds a percent-encoded announce query", func() {
+		Expect(req.Query()).To(Equal(
+			"info_hash=%00%01%20%2B%2F%3F%26%3D~abcdefghijk&peer_id=-BN0001-%FF%FE0123456789" +
+				"&port=6881&uploaded=1&downloaded=2&left=3&compact=1&event=started&numwant=50" +
+				"&key=a1b2&trackerid=trk%20id",
+		))
+		Expect(req.URL("http://t/announce?passkey=x")).To(HavePrefix("http://t/announce?passkey=x&info_hash="))
+	})
+
+	It("announces and decodes the response", func() {
+		body = "d8:completei1e10:incompletei2e8:intervali900e5:peers6:\x7f\x00\x00\x01\x1a\xe1e"
+
+		resp, err := client.Announce(context.Background(), server.URL+"/announce", req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Interval).To(Equal(int64(900)))
+		Expect(resp.Peers).To(Equal(ben.Peers{netip.MustParseAddrPort("127.0.0.1:6881")}))
+
+		Expect(lastReq.URL.Path).To(Equal("/announce"))
+		Expect(lastReq.Header.Get("User-Agent")).To(Equal("ben/test"))
+
+		query, err := url.ParseQuery(lastReq.URL.RawQuery)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(query.Get("info_hash")).To(Equal(string(req.InfoHash[:])))
+		Expect(query.Get("peer_id")).To(Equal(string(req.PeerID[:])))
+		Expect(query.Get("event")).To(Equal("started"))
+	})
+
+	It("returns the tracker failure as an error", func() {
+		status = http.StatusBadRequest
+		body = "d14:failure reason7:invalide"
+
+		_, err := client.Announce(context.Background(), server.URL+"/announce", req)
+		Expect(err).To(MatchError(ben.TrackerFailureError{Reason: "invalid"}))
+	})
+
+	It("reports non-bencoded error pages with their status", func() {
+		status = http.StatusBadGateway
+		body = "<html>bad gateway</html>"
+
+		_, err := client.Announce(context.Background(), server.URL+"/announce", req)
+		Expect(err).To(MatchError(ben.HTTPStatusError{StatusCode: http.StatusBadGateway}))
+	})
+
+	It("stops reading oversized responses", func() {
+		const size = 17 * 1024 * 1024
+		body = "d5:peers" + strconv.Itoa(size) + ":" + strings.Repeat("a", size) + "e"
+
+		_, err := client.Announce(context.Background(), server.URL+"/announce", req)
+		Expect(err).To(MatchError(io.EOF))
+	})
+
+	It("scrapes the derived scrape URL", func() {
+		body = "d5:filesd20:" + string(req.InfoHash[:]) + "d8:completei4e10:downloadedi5e10:incompletei6eeee"
+
+		resp, err := client.Scrape(context.Background(), server.URL+"/announce", req.InfoHash)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Files[req.InfoHash]).To(Equal(ben.ScrapeFile{Complete: 4, Downloaded: 5, Incomplete: 6}))
+		Expect(lastReq.URL.Path).To(Equal("/scrape"))
+		Expect(strings.Count(lastReq.URL.RawQuery, "info_hash=")).To(Equal(1))
+	})
+})
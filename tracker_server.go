@@ -0,0 +1,251 @@
+package ben
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAnnounceInterval = 30 * time.Minute
+	defaultNumWant          = 50
+	maxNumWant              = 200
+)
+
+// TrackerServer is an http.Handler serving `/announce` and `/scrape`
+// from an in-memory swarm store, create it with NewTrackerServer.
+type TrackerServer struct {
+	Swarms      *Swarms
+	Interval    time.Duration
+	MinInterval time.Duration
+	// Allowed restricts the served info-hashes, every info-hash is served when nil.
+	Allowed func(InfoHash) bool
+}
+
+// NewTrackerServer creates a tracker server asking peers to announce every interval,
+// peers are dropped after missing two announces.
+func NewTrackerServer(interval time.Duration) *TrackerServer {
+	if interval <= 0 {
+		interval = defaultAnnounceInterval
+	}
+
+	return &TrackerServer{
+		Swarms:   NewSwarms(2 * interval), //nolint: mnd // see above
+		Interval: interval,
+	}
+}
+
+func (s *TrackerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		resp Element
+		err  error
+	)
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/announce"):
+		resp, err = s.announce(r)
+	case strings.HasSuffix(r.URL.Path, "/scrape"):
+		resp, err = s.scrape(r)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	// failures are reported in the body, as clients expect bencode regardless of the status
+	if failure, ok := err.(TrackerFailureError); ok { //nolint: errorlint // never wrapped
+		resp, err = AnnounceResponse{FailureReason: failure.Reason}.TryInto()
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write(resp.Encode())
+}
+
+func (s *TrackerServer) allowed(hash InfoHash) bool {
+	return s.Allowed == nil || s.Allowed(hash)
+}
+
+func (s *TrackerServer) announce(r *http.Request) (Element, error) {
+	query, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		return nil, TrackerFailureError{"malformed query"}
+	}
+
+	req, err := parseAnnounceQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.allowed(req.InfoHash) {
+		return nil, TrackerFailureError{"unregistered torrent"}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return nil, err
+	}
+
+	numWant := defaultNumWant
+	if req.NumWant > 0 {
+		numWant = min(req.NumWant, maxNumWant)
+	}
+
+	peers, complete, incomplete := s.Swarms.Announce(req.InfoHash, SwarmPeer{
+		ID:   req.PeerID,
+		Addr: netip.AddrPortFrom(addr.Unmap(), req.Port),
+		Left: req.Left,
+	}, req.Event, numWant)
+
+	resp := AnnounceResponse{
+		Interval:    int64(s.Interval.Seconds()),
+		MinInterval: int64(s.MinInterval.Seconds()),
+		Complete:    int64(complete),
+		Incomplete:  int64(incomplete),
+	}
+
+	if req.Compact {
+		for _, peer := range peers {
+			if peer.Addr.Addr().Is4() {
+				resp.Peers = append(resp.Peers, peer.Addr)
+			} else {
+				resp.Peers6 = append(resp.Peers6, peer.Addr)
+			}
+		}
+
+		dict, err := resp.TryInto()
+		if err != nil {
+			return nil, err
+		}
+
+		// `peers` is required even when there is no other peer
+		if _, ok := dict.Val["peers"]; !ok {
+			dict.Val["peers"] = Str("")
+		}
+
+		return dict, nil
+	}
+
+	dict, err := resp.TryInto()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := nonCompactPeers(peers, query.Get("no_peer_id") == "1")
+	if err != nil {
+		return nil, err
+	}
+
+	dict.Val["peers"] = list
+
+	return dict, nil
+}
+
+func nonCompactPeers(peers []SwarmPeer, noPeerID bool) (List, error) {
+	list := make([]Element, 0, len(peers))
+
+	for _, peer := range peers {
+		pd := peerDict{IP: peer.Addr.Addr().String(), Port: int64(peer.Addr.Port())}
+		if !noPeerID {
+			pd.PeerID = string(peer.ID[:])
+		}
+
+		dict, err := castFromStructIntoDictionary(pd)
+		if err != nil {
+			return Lst(list), err
+		}
+
+		list = append(list, dict)
+	}
+
+	return Lst(list), nil
+}
+
+func parseAnnounceQuery(query url.Values) (AnnounceRequest, error) {
+	var req AnnounceRequest
+
+	infoHash := query.Get("info_hash")
+	if len(infoHash) != len(req.InfoHash) {
+		return req, TrackerFailureError{"invalid info_hash"}
+	}
+
+	peerID := query.Get("peer_id")
+	if len(peerID) != len(req.PeerID) {
+		return req, TrackerFailureError{"invalid peer_id"}
+	}
+
+	port, err := strconv.ParseUint(query.Get("port"), 10, 16)
+	if err != nil || port == 0 {
+		return req, TrackerFailureError{"invalid port"}
+	}
+
+	req.InfoHash = InfoHash([]byte(infoHash))
+	req.PeerID = PeerID([]byte(peerID))
+	req.Port = uint16(port)
+	req.Event = AnnounceEvent(query.Get("event"))
+	req.Compact = query.Get("compact") == "1"
+	req.Key = query.Get("key")
+	req.TrackerID = query.Get("trackerid")
+
+	for name, dst := range map[string]*int64{
+		"uploaded":   &req.Uploaded,
+		"downloaded": &req.Downloaded,
+		"left":       &req.Left,
+	} {
+		if *dst, err = strconv.ParseInt(query.Get(name), 10, 64); err != nil {
+			return req, TrackerFailureError{"invalid " + name}
+		}
+	}
+
+	if numWant := query.Get("numwant"); numWant != "" {
+		if req.NumWant, err = strconv.Atoi(numWant); err != nil {
+			return req, TrackerFailureError{"invalid numwant"}
+		}
+	}
+
+	switch req.Event {
+	case EventNone, EventStarted, EventStopped, EventCompleted, EventPaused:
+	default:
+		return req, TrackerFailureError{"invalid event"}
+	}
+
+	return req, nil
+}
+
+func (s *TrackerServer) scrape(r *http.Request) (Element, error) {
+	query, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		return nil, TrackerFailureError{"malformed query"}
+	}
+
+	var hashes []InfoHash
+
+	for _, hash := range query["info_hash"] {
+		if len(hash) != len(InfoHash{}) {
+			return nil, TrackerFailureError{"invalid info_hash"}
+		}
+
+		if s.allowed(InfoHash([]byte(hash))) {
+			hashes = append(hashes, InfoHash([]byte(hash)))
+		}
+	}
+
+	// a full scrape is only served when every info-hash is allowed
+	if len(hashes) == 0 && (len(query["info_hash"]) > 0 || s.Allowed != nil) {
+		return ScrapeResponse{}.TryInto()
+	}
+
+	return ScrapeResponse{Files: s.Swarms.Scrape(hashes...)}.TryInto()
+}
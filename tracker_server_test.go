@@ -0,0 +1,196 @@
+package ben_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTP tracker server", func() {
+	var (
+		tracker  *ben.TrackerServer
+		server   *httptest.Server
+		client   ben.TrackerClient
+		announce string
+		hash     ben.InfoHash
+		seeder   ben.AnnounceRequest
+		leecher  ben.AnnounceRequest
+	)
+
+	BeforeEach(func() {
+		tracker = ben.NewTrackerServer(time.Minute)
+		server = httptest.NewServer(tracker)
+		client = ben.TrackerClient{HTTPClient: server.Client()}
+		announce = server.URL + "/announce"
+
+		hash = ben.InfoHash([]byte("01234567890123456789"))
+		seeder = ben.AnnounceRequest{
+			InfoHash: hash,
+			PeerID:   ben.PeerID([]byte("-BN0001-seeder000000")),
+			Port:     6881,
+			Event:    ben.EventStarted,
+			Compact:  true,
+		}
+		leecher = ben.AnnounceRequest{
+			InfoHash: hash,
+			PeerID:   ben.PeerID([]byte("-BN0001-leecher00000")),
+			Port:     6882,
+			Left:     100,
+			Event:    ben.EventStarted,
+			Compact:  true,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns other peers of the swarm in compact form", func() {
+		resp, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Peers).To(BeEmpty())
+		Expect(resp.Interval).To(Equal(int64(60)))
+
+		resp, err = client.Announce(context.Background(), announce, leecher)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Complete).To(Equal(int64(1)))
+		Expect(resp.Incomplete).To(Equal(int64(1)))
+		Expect(resp.Peers).To(HaveLen(1))
+		Expect(resp.Peers[0].Port()).To(Equal(uint16(6881)))
+		Expect(resp.Peers[0].Addr().String()).To(Equal("127.0.0.1"))
+	})
+
+	It("returns an empty peer list to the only peer of a swarm", func() {
+		httpResp, err := server.Client().Get(seeder.URL(announce))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = httpResp.Body.Close() }()
+
+		body, err := io.ReadAll(httpResp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring("5:peers0:"))
+	})
+
+	It("keeps partial seeds that paused in the swarm", func() {
+		leecher.Event = ben.EventPaused
+		_, err := client.Announce(context.Background(), announce, leecher)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Peers).To(HaveLen(1))
+		Expect(resp.Peers[0].Port()).To(Equal(uint16(6882)))
+	})
+
+	It("returns peers with their id in non-compact form", func() {
+		_, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+
+		leecher.Compact = false
+
+		httpResp, err := server.Client().Get(leecher.URL(announce))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = httpResp.Body.Close() }()
+
+		body, err := io.ReadAll(httpResp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring("5:peersld2:ip9:127.0.0.17:peer id20:-BN0001-seeder0000004:porti6881eee"))
+	})
+
+	It("drops peers that stopped", func() {
+		_, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+
+		seeder.Event = ben.EventStopped
+		_, err = client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+
+		resp, err := client.Announce(context.Background(), announce, leecher)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Peers).To(BeEmpty())
+	})
+
+	It("does not track swarms for peers that stopped", func() {
+		seeder.Event = ben.EventStopped
+		_, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(tracker.Swarms.Scrape()).To(BeEmpty())
+	})
+
+	It("does not track new swarms once full", func() {
+		tracker.Swarms.MaxSwarms = 1
+
+		_, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+
+		leecher.InfoHash = ben.InfoHash([]byte("abcdefghijabcdefghij"))
+		resp, err := client.Announce(context.Background(), announce, leecher)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Incomplete).To(BeZero())
+		Expect(tracker.Swarms.Scrape()).To(HaveLen(1))
+
+		tracker.Swarms.Expire(time.Now().Add(3 * time.Minute))
+
+		resp, err = client.Announce(context.Background(), announce, leecher)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Incomplete).To(Equal(int64(1)))
+	})
+
+	It("expires peers that stopped announcing", func() {
+		_, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+
+		tracker.Swarms.Expire(time.Now().Add(3 * time.Minute))
+
+		scrape, err := client.Scrape(context.Background(), announce, hash)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scrape.Files).To(BeEmpty())
+	})
+
+	It("serves scrape statistics", func() {
+		_, err := client.Announce(context.Background(), announce, leecher)
+		Expect(err).NotTo(HaveOccurred())
+
+		leecher.Event = ben.EventCompleted
+		leecher.Left = 0
+		_, err = client.Announce(context.Background(), announce, leecher)
+		Expect(err).NotTo(HaveOccurred())
+
+		scrape, err := client.Scrape(context.Background(), announce, hash)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scrape.Files).To(Equal(ben.ScrapeFiles{hash: {Complete: 1, Downloaded: 1}}))
+	})
+
+	It("rejects info-hashes outside of the allow-list", func() {
+		tracker.Allowed = func(h ben.InfoHash) bool { return h != hash }
+
+		_, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).To(MatchError(ben.TrackerFailureError{Reason: "unregistered torrent"}))
+
+		scrape, err := client.Scrape(context.Background(), announce)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scrape.Files).To(BeEmpty())
+	})
+
+	It("reports malformed requests as failures", func() {
+		_, err := client.Announce(context.Background(), announce, ben.AnnounceRequest{InfoHash: hash})
+		Expect(err).To(MatchError(ben.TrackerFailureError{Reason: "invalid port"}))
+	})
+
+	It("answers unknown paths with 404", func() {
+		httpResp, err := server.Client().Get(server.URL + "/other")
+		Expect(err).NotTo(HaveOccurred())
+		_ = httpResp.Body.Close()
+		Expect(httpResp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,115 @@
+package ben_test
+
+import (
+	"bufio"
+	"bytes"
+	"net/netip"
+
+	"github.com/fudanchii/ben"
+	"github.com/fudanchii/infr"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+func decodeAnnounceResponse(source string) (ben.AnnounceResponse, error) {
+	dict, err := ben.Decode[ben.Dictionary](bufio.NewReader(bytes.NewBufferString(source)))
+	if err != nil {
+		return ben.AnnounceResponse{}, err
+	}
+
+	return infr.TryFrom[ben.Dictionary, ben.AnnounceResponse](dict).TryInto()
+}
+
+var _ = Describe("Tracker announce response", func() {
+	It("decodes compact peers", func() {
+		source := "d8:completei3e10:incompletei1e8:intervali1800e12:min intervali60e" +
+			"5:peers12:\x7f\x00\x00\x01\x1a\xe1\x0a\x00\x00\x02\x1a\xe2" +
+			"6:peers618:\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01\x1a\xe3" +
+			"10:tracker id3:abce"
+
+		resp, err := decodeAnnounceResponse(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Err()).NotTo(HaveOccurred())
+		Expect(resp.Interval).To(Equal(int64(1800)))
+		Expect(resp.MinInterval).To(Equal(int64(60)))
+		Expect(resp.Complete).To(Equal(int64(3)))
+		Expect(resp.Incomplete).To(Equal(int64(1)))
+		Expect(resp.TrackerID).To(Equal("abc"))
+		Expect(resp.Peers).To(Equal(ben.Peers{
+			netip.MustParseAddrPort("127.0.0.1:6881"),
+			netip.MustParseAddrPort("10.0.0.2:6882"),
+		}))
+		Expect(resp.Peers6).To(Equal(ben.Peers6{netip.MustParseAddrPort("[::1]:6883")}))
+		Expect(resp.AllPeers()).To(HaveLen(3))
+
+		dict, err := resp.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(Equal(source))
+	})
+
+	//nolint: lll
+	It("decodes peers given as a list of dictionaries", func() {
+		source := "d8:intervali900e5:peersld2:ip9:127.0.0.17:peer id20:-XX0001-0123456789ab4:porti6881eed2:ip7:dht.lan4:porti1eed2:ip3:::14:porti6882eeee"
+
+		resp, err := decodeAnnounceResponse(source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Peers).To(Equal(ben.Peers{
+			netip.MustParseAddrPort("127.0.0.1:6881"),
+			netip.MustParseAddrPort("[::1]:6882"),
+		}))
+		Expect(resp.PeerHosts).To(Equal([]string{"dht.lan:1"}))
+
+		dict, err := resp.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(ContainSubstring("d2:ip7:dht.lan4:porti1ee"))
+	})
+
+	It("rejects peers with a port out of range", func() {
+		_, err := decodeAnnounceResponse("d8:intervali900e5:peersld2:ip9:127.0.0.14:porti65536eeee")
+		Expect(err).To(MatchError(ben.ErrInvalidPeerPort))
+	})
+
+	It("refuses to write addresses that do not fit compact entries", func() {
+		_, err := ben.CompactPeers([]netip.AddrPort{{}}, 6)
+		Expect(err).To(MatchError(ben.ErrInvalidCompactAddr))
+
+		_, err = ben.AnnounceResponse{Peers: ben.Peers{{}}}.TryInto()
+		Expect(err).To(MatchError(ben.ErrInvalidCompactAddr))
+
+		_, err = ben.AnnounceResponse{Peers6: ben.Peers6{netip.MustParseAddrPort("[::ffff:10.0.0.1]:6881")}}.TryInto()
+		Expect(err).To(MatchError(ben.ErrInvalidCompactAddr))
+
+		compact, err := ben.CompactPeers([]netip.AddrPort{netip.MustParseAddrPort("[::ffff:10.0.0.1]:6881")}, 6)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(compact).To(Equal([]byte{10, 0, 0, 1, 0x1a, 0xe1}))
+	})
+
+	DescribeTable("rejects compact entry sizes other than 6 and 18",
+		func(size int) {
+			_, err := ben.ParseCompactPeers(make([]byte, 18), size)
+			Expect(err).To(MatchError(ben.ErrInvalidCompactAddr))
+		},
+		Entry("zero", 0),
+		Entry("negative", -6),
+		Entry("a multiple of neither", 9),
+	)
+
+	It("reports the failure reason as an error", func() {
+		resp, err := decodeAnnounceResponse("d14:failure reason12:unregisterede")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Err()).To(MatchError(ben.TrackerFailureError{Reason: "unregistered"}))
+
+		dict, err := resp.TryInto()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(dict.Encode())).To(Equal("d14:failure reason12:unregisterede"))
+	})
+
+	It("rejects truncated compact peers", func() {
+		_, err := decodeAnnounceResponse("d8:intervali900e5:peers5:\x7f\x00\x00\x01\x1ae")
+		Expect(err).To(MatchError(ben.ErrInvalidCompactAddr))
+	})
+})
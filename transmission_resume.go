@@ -0,0 +1,106 @@
+package ben
+
+import (
+	"bufio"
+	"bytes"
+	"time"
+)
+
+// Transmission stores these instead of a bitfield when no piece or every piece is downloaded.
+const (
+	transmissionAll  = "all"
+	transmissionNone = "none"
+)
+
+// TransmissionResume is a Transmission `.resume` file.
+type TransmissionResume struct {
+	Name           string `ben:"name,omitempty"`
+	DestinationDir string `ben:"destination,omitempty"`
+	IncompleteDir  string `ben:"incomplete-dir,omitempty"`
+
+	Added    time.Time `ben:"added-date,omitempty"`
+	Done     time.Time `ben:"done-date,omitempty"`
+	Activity time.Time `ben:"activity-date,omitempty"`
+
+	Downloaded int64 `ben:"downloaded,omitempty"`
+	Uploaded   int64 `ben:"uploaded,omitempty"`
+	Corrupt    int64 `ben:"corrupt,omitempty"`
+	Paused     bool  `ben:"paused,omitempty"`
+
+	LabelList   []string             `ben:"labels,omitempty"`
+	PieceStates TransmissionProgress `ben:"progress,omitempty"`
+}
+
+// TransmissionProgress is the `progress` dictionary of a resume file,
+// it tracks 16 KiB blocks rather than pieces.
+type TransmissionProgress struct {
+	// Blocks is a bitfield of the downloaded blocks, or `all` or `none`.
+	Blocks string `ben:"blocks,omitempty"`
+	// Have is only set to `all` by older versions, for completed torrents.
+	Have string `ben:"have,omitempty"`
+	// Bitfield is the block bitfield of older versions.
+	Bitfield string `ben:"bitfield,omitempty"`
+}
+
+func (TransmissionResume) TryFrom(d Dictionary) (TransmissionResume, error) {
+	return castFromDictionaryInto[TransmissionResume](d)
+}
+
+func (r TransmissionResume) TryInto() (Dictionary, error) {
+	return castFromStructIntoDictionary(r)
+}
+
+func (TransmissionProgress) TryFrom(d Dictionary) (TransmissionProgress, error) {
+	return castFromDictionaryInto[TransmissionProgress](d)
+}
+
+func (p TransmissionProgress) TryInto() (Dictionary, error) {
+	return castFromStructIntoDictionary(p)
+}
+
+func ParseTransmissionResume(b []byte) (TransmissionResume, error) {
+	dict, err := Decode[Dictionary](bufio.NewReader(bytes.NewReader(b)))
+	if err != nil {
+		return TransmissionResume{}, err
+	}
+
+	return TransmissionResume{}.TryFrom(dict)
+}
+
+func (r TransmissionResume) Destination() string {
+	return r.DestinationDir
+}
+
+// Progress returns the pieces whose blocks are all downloaded, looking at `blocks` first
+// then at the `have` and `bitfield` keys of older versions.
+func (r TransmissionResume) Progress(info Info) Bitfield {
+	blocks := r.PieceStates.Blocks
+	if blocks == "" {
+		if r.PieceStates.Have == transmissionAll {
+			return fullBitfield(info.PieceCount())
+		}
+
+		blocks = r.PieceStates.Bitfield
+	}
+
+	switch blocks {
+	case transmissionAll:
+		return fullBitfield(info.PieceCount())
+	case transmissionNone:
+		return NewBitfield(info.PieceCount())
+	default:
+		return piecesFromBlocks(Bitfield(blocks), info)
+	}
+}
+
+func (r TransmissionResume) AddedDate() time.Time {
+	return r.Added
+}
+
+func (r TransmissionResume) Labels() []string {
+	return r.LabelList
+}
+
+func (r TransmissionResume) Ratio() float64 {
+	return ratio(r.Uploaded, r.Downloaded)
+}
@@ -0,0 +1,396 @@
+package ben
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// UDP tracker protocol from BEP 15, with the URL data option from BEP 41.
+const (
+	udpProtocolID = 0x41727101980
+
+	udpActionConnect  = 0
+	udpActionAnnounce = 1
+	udpActionScrape   = 2
+	udpActionError    = 3
+
+	udpOptionEnd     = 0
+	udpOptionNOP     = 1
+	udpOptionURLData = 2
+
+	udpConnectionTTL = time.Minute
+	udpMaxPacketSize = 2048
+	udpMaxScrape     = 74
+
+	defaultUDPTimeout    = 15 * time.Second
+	defaultUDPMaxRetries = 8
+)
+
+var udpEvents = map[AnnounceEvent]uint32{
+	EventNone:      0,
+	EventCompleted: 1,
+	EventStarted:   2, //nolint: mnd // event ids from BEP 15
+	EventStopped:   3, //nolint: mnd // event ids from BEP 15
+}
+
+type udpConnection struct {
+	id      uint64
+	expires time.Time
+}
+
+// UDPTrackerClient talks to UDP trackers, it is safe for concurrent use.
+type UDPTrackerClient struct {
+	// Timeout is the initial retransmit timeout, doubled on every retry. Defaults to 15 seconds.
+	Timeout time.Duration
+	// MaxRetries defaults to 8, as in BEP 15.
+	MaxRetries int
+
+	mu          sync.Mutex
+	connections map[string]udpConnection
+}
+
+// Announce sends req to the tracker at a udp:// announce URL.
+func (c *UDPTrackerClient) Announce(ctx context.Context, announce string, req AnnounceRequest) (AnnounceResponse, error) {
+	var resp AnnounceResponse
+
+	u, err := parseUDPTrackerURL(announce)
+	if err != nil {
+		return resp, err
+	}
+
+	numWant := int32(-1)
+	if req.NumWant > 0 {
+		numWant = int32(min(req.NumWant, 1<<31-1)) //nolint: gosec // clamped
+	}
+
+	packet := make([]byte, 0, 98) //nolint: mnd // announce request size
+	packet = append(packet, req.InfoHash[:]...)
+	packet = append(packet, req.PeerID[:]...)
+	packet = binary.BigEndian.AppendUint64(packet, uint64(req.Downloaded)) //nolint: gosec // wire format
+	packet = binary.BigEndian.AppendUint64(packet, uint64(req.Left))       //nolint: gosec // wire format
+	packet = binary.BigEndian.AppendUint64(packet, uint64(req.Uploaded))   //nolint: gosec // wire format
+	packet = binary.BigEndian.AppendUint32(packet, udpEvents[req.Event])
+	packet = binary.BigEndian.AppendUint32(packet, 0) // ip, let the tracker use the sender address
+	packet = binary.BigEndian.AppendUint32(packet, udpKey(req.Key))
+	packet = binary.BigEndian.AppendUint32(packet, uint32(numWant)) //nolint: gosec // -1 is part of the protocol
+	packet = binary.BigEndian.AppendUint16(packet, req.Port)
+	packet = appendURLData(packet, u.RequestURI())
+
+	payload, remote, err := c.roundTrip(ctx, u.Host, udpActionAnnounce, packet)
+	if err != nil {
+		return resp, err
+	}
+
+	const header = 12
+	if len(payload) < header {
+		return resp, ErrInvalidUDPResponse
+	}
+
+	resp.Interval = int64(binary.BigEndian.Uint32(payload[0:4]))
+	resp.Incomplete = int64(binary.BigEndian.Uint32(payload[4:8]))
+	resp.Complete = int64(binary.BigEndian.Uint32(payload[8:12]))
+
+	// the peer address family follows the one the request was sent over
+	if remote.Addr().Unmap().Is4() {
+		peers, err := ParseCompactPeers(payload[header:], compactIPv4Len)
+		resp.Peers = peers
+
+		return resp, err
+	}
+
+	peers, err := ParseCompactPeers(payload[header:], compactIPv6Len)
+	resp.Peers6 = peers
+
+	return resp, err
+}
+
+// Scrape asks the tracker at a udp:// announce URL for statistics of the given info-hashes.
+func (c *UDPTrackerClient) Scrape(ctx context.Context, announce string, hashes ...InfoHash) (ScrapeResponse, error) {
+	var resp ScrapeResponse
+
+	if len(hashes) > udpMaxScrape {
+		return resp, ErrTooManyScrapeHash
+	}
+
+	u, err := parseUDPTrackerURL(announce)
+	if err != nil {
+		return resp, err
+	}
+
+	packet := make([]byte, 0, len(hashes)*len(InfoHash{}))
+	for _, hash := range hashes {
+		packet = append(packet, hash[:]...)
+	}
+
+	payload, _, err := c.roundTrip(ctx, u.Host, udpActionScrape, packet)
+	if err != nil {
+		return resp, err
+	}
+
+	const entrySize = 12
+	if len(payload) != len(hashes)*entrySize {
+		return resp, ErrInvalidUDPResponse
+	}
+
+	resp.Files = make(ScrapeFiles, len(hashes))
+	for idx, hash := range hashes {
+		entry := payload[idx*entrySize:]
+		resp.Files[hash] = ScrapeFile{
+			Complete:   int64(binary.BigEndian.Uint32(entry[0:4])),
+			Downloaded: int64(binary.BigEndian.Uint32(entry[4:8])),
+			Incomplete: int64(binary.BigEndian.Uint32(entry[8:12])),
+		}
+	}
+
+	return resp, nil
+}
+
+func parseUDPTrackerURL(announce string) (*url.URL, error) {
+	u, err := url.Parse(announce)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "udp" || u.Port() == "" {
+		return nil, ErrNotUDPTracker
+	}
+
+	return u, nil
+}
+
+// udpKey turns the announce key into the 32-bit key of the UDP protocol,
+// hex keys are used as is.
+func udpKey(key string) uint32 {
+	if k, err := strconv.ParseUint(key, 16, 32); err == nil {
+		return uint32(k)
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return h.Sum32()
+}
+
+// appendURLData appends the BEP 41 URL data option, split into chunks of at most 255 bytes.
+func appendURLData(packet []byte, requestURI string) []byte {
+	if requestURI == "" || requestURI == "/" {
+		return packet
+	}
+
+	const maxChunk = 255
+
+	data := []byte(requestURI)
+	for len(data) > 0 {
+		chunk := data[:min(len(data), maxChunk)]
+		packet = append(packet, udpOptionURLData, byte(len(chunk)))
+		packet = append(packet, chunk...)
+		data = data[len(chunk):]
+	}
+
+	return append(packet, udpOptionEnd)
+}
+
+// parseURLData reads BEP 41 options following an announce request.
+func parseURLData(options []byte) string {
+	var data []byte
+
+	for len(options) > 0 {
+		switch options[0] {
+		case udpOptionEnd:
+			return string(data)
+
+		case udpOptionNOP:
+			options = options[1:]
+
+		case udpOptionURLData:
+			if len(options) < 2 || len(options) < 2+int(options[1]) {
+				return string(data)
+			}
+
+			data = append(data, options[2:2+int(options[1])]...)
+			options = options[2+int(options[1]):]
+
+		default:
+			return string(data)
+		}
+	}
+
+	return string(data)
+}
+
+func (c *UDPTrackerClient) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+
+	return defaultUDPTimeout
+}
+
+func (c *UDPTrackerClient) maxRetries() int {
+	if c.MaxRetries > 0 {
+		return c.MaxRetries
+	}
+
+	return defaultUDPMaxRetries
+}
+
+// roundTrip sends an action with payload, connecting first when needed,
+// and returns the payload of the response following the action and transaction id.
+func (c *UDPTrackerClient) roundTrip(ctx context.Context, host string, action uint32, payload []byte) ([]byte, netip.AddrPort, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "udp", host)
+	if err != nil {
+		return nil, netip.AddrPort{}, err
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	// unblock pending reads as soon as ctx is done
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	remote := conn.RemoteAddr().(*net.UDPAddr).AddrPort() //nolint: forcetypeassert // dialed udp
+
+	for attempt := 0; attempt <= c.maxRetries(); attempt++ {
+		deadline := time.Now().Add(c.timeout() << attempt)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+
+		connID, err := c.connect(ctx, conn, host, deadline)
+		if isTimeout(err) {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, remote, ctxErr
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return nil, remote, err
+		}
+
+		resp, err := udpExchange(ctx, conn, connID, action, payload, deadline)
+		if isTimeout(err) {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, remote, ctxErr
+			}
+
+			continue
+		}
+
+		// the tracker may have rejected the connection id, get a new one next time
+		var failure TrackerFailureError
+		if errors.As(err, &failure) {
+			c.mu.Lock()
+			delete(c.connections, host)
+			c.mu.Unlock()
+		}
+
+		return resp, remote, err
+	}
+
+	return nil, remote, ErrUDPTrackerTimeout
+}
+
+func (c *UDPTrackerClient) connect(ctx context.Context, conn net.Conn, host string, deadline time.Time) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	cached, ok := c.connections[host]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(cached.expires) {
+		return cached.id, nil
+	}
+
+	resp, err := udpExchange(ctx, conn, udpProtocolID, udpActionConnect, nil, deadline)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(resp) < 8 { //nolint: mnd // connection id size
+		return 0, ErrInvalidUDPResponse
+	}
+
+	id := binary.BigEndian.Uint64(resp[:8])
+
+	c.mu.Lock()
+	if c.connections == nil {
+		c.connections = make(map[string]udpConnection)
+	}
+	c.connections[host] = udpConnection{id: id, expires: time.Now().Add(udpConnectionTTL)}
+	c.mu.Unlock()
+
+	return id, nil
+}
+
+// udpExchange sends a single request and waits for the response with the same transaction id
+// until deadline. roundTrip moves the deadline to now once ctx is done.
+func udpExchange(
+	ctx context.Context, conn net.Conn, connID uint64, action uint32, payload []byte, deadline time.Time,
+) ([]byte, error) {
+	var txID [4]byte
+	_, _ = rand.Read(txID[:])
+
+	packet := make([]byte, 0, 16+len(payload)) //nolint: mnd // request header size
+	packet = binary.BigEndian.AppendUint64(packet, connID)
+	packet = binary.BigEndian.AppendUint32(packet, action)
+	packet = append(packet, txID[:]...)
+	packet = append(packet, payload...)
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	// ctx may have been done before the deadline was set, which then overrode the cancellation
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(packet); err != nil {
+		return nil, err
+	}
+
+	buff := make([]byte, udpMaxPacketSize)
+
+	for {
+		n, err := conn.Read(buff)
+		if err != nil {
+			return nil, err
+		}
+
+		const header = 8
+		if n < header || [4]byte(buff[4:8]) != txID {
+			continue // stray or late response to an earlier attempt
+		}
+
+		respAction := binary.BigEndian.Uint32(buff[:4])
+		if respAction == udpActionError {
+			return nil, TrackerFailureError{string(buff[header:n])}
+		}
+
+		if respAction != action {
+			return nil, ErrInvalidUDPResponse
+		}
+
+		return append([]byte(nil), buff[header:n]...), nil
+	}
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,211 @@
+package ben
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// UDPTrackerServer serves the UDP tracker protocol from a swarm store,
+// which may be shared with a TrackerServer. Create it with NewUDPTrackerServer.
+type UDPTrackerServer struct {
+	Swarms   *Swarms
+	Interval time.Duration
+	// Allowed restricts the served info-hashes, every info-hash is served when nil.
+	Allowed func(InfoHash) bool
+	// URLData is called with the BEP 41 URL data of each announce, a non-nil error
+	// rejects the announce with the error message.
+	URLData func(string) error
+
+	secret [32]byte
+}
+
+func NewUDPTrackerServer(swarms *Swarms, interval time.Duration) *UDPTrackerServer {
+	if interval <= 0 {
+		interval = defaultAnnounceInterval
+	}
+
+	s := &UDPTrackerServer{Swarms: swarms, Interval: interval}
+	_, _ = rand.Read(s.secret[:])
+
+	return s
+}
+
+// Serve handles requests from conn until it is closed.
+func (s *UDPTrackerServer) Serve(conn net.PacketConn) error {
+	buff := make([]byte, udpMaxPacketSize)
+
+	for {
+		n, addr, err := conn.ReadFrom(buff)
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		udpAddr, ok := addr.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+
+		if resp := s.handle(buff[:n], udpAddr.AddrPort()); resp != nil {
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+// connectionID derives a connection id from the client ip, valid for the current
+// and the previous minute, so no state has to be kept per client.
+// The port is left out since clients may use a new socket for every request.
+func (s *UDPTrackerServer) connectionID(addr netip.Addr, window int64) uint64 {
+	buff := append([]byte(nil), s.secret[:]...)
+	buff = append(buff, addr.Unmap().AsSlice()...)
+	buff = binary.BigEndian.AppendUint64(buff, uint64(window)) //nolint: gosec // time window
+
+	sum := sha256.Sum256(buff)
+
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+func (s *UDPTrackerServer) validConnection(id uint64, addr netip.Addr) bool {
+	window := time.Now().Unix() / int64(udpConnectionTTL.Seconds())
+	return id == s.connectionID(addr, window) || id == s.connectionID(addr, window-1)
+}
+
+func (s *UDPTrackerServer) handle(packet []byte, addr netip.AddrPort) []byte {
+	const header = 16
+	if len(packet) < header {
+		return nil
+	}
+
+	connID := binary.BigEndian.Uint64(packet[0:8])
+	action := binary.BigEndian.Uint32(packet[8:12])
+	txID := packet[12:16]
+	payload := packet[header:]
+
+	resp := binary.BigEndian.AppendUint32(nil, action)
+	resp = append(resp, txID...)
+
+	if action == udpActionConnect {
+		if connID != udpProtocolID {
+			return nil
+		}
+
+		window := time.Now().Unix() / int64(udpConnectionTTL.Seconds())
+
+		return binary.BigEndian.AppendUint64(resp, s.connectionID(addr.Addr(), window))
+	}
+
+	if !s.validConnection(connID, addr.Addr()) {
+		return udpError(txID, "invalid connection id")
+	}
+
+	var (
+		body []byte
+		err  error
+	)
+
+	switch action {
+	case udpActionAnnounce:
+		body, err = s.announce(payload, addr)
+	case udpActionScrape:
+		body, err = s.scrape(payload)
+	default:
+		err = TrackerFailureError{"unknown action"}
+	}
+
+	var failure TrackerFailureError
+	if errors.As(err, &failure) {
+		return udpError(txID, failure.Reason)
+	}
+
+	return append(resp, body...)
+}
+
+func udpError(txID []byte, message string) []byte {
+	resp := binary.BigEndian.AppendUint32(nil, udpActionError)
+	resp = append(resp, txID...)
+
+	return append(resp, message...)
+}
+
+func (s *UDPTrackerServer) announce(payload []byte, addr netip.AddrPort) ([]byte, error) {
+	const size = 82
+	if len(payload) < size {
+		return nil, TrackerFailureError{"malformed announce"}
+	}
+
+	hash := InfoHash(payload[0:20])
+	if s.Allowed != nil && !s.Allowed(hash) {
+		return nil, TrackerFailureError{"unregistered torrent"}
+	}
+
+	if s.URLData != nil {
+		if err := s.URLData(parseURLData(payload[size:])); err != nil {
+			return nil, TrackerFailureError{err.Error()}
+		}
+	}
+
+	var event AnnounceEvent
+	for name, id := range udpEvents {
+		if id == binary.BigEndian.Uint32(payload[64:68]) {
+			event = name
+		}
+	}
+
+	numWant := defaultNumWant
+	if want := int32(binary.BigEndian.Uint32(payload[76:80])); want > 0 { //nolint: gosec // -1 means default
+		numWant = min(int(want), maxNumWant)
+	}
+
+	clientAddr := netip.AddrPortFrom(addr.Addr().Unmap(), binary.BigEndian.Uint16(payload[80:82]))
+
+	peers, complete, incomplete := s.Swarms.Announce(hash, SwarmPeer{
+		ID:   PeerID(payload[20:40]),
+		Addr: clientAddr,
+		Left: int64(binary.BigEndian.Uint64(payload[48:56])), //nolint: gosec // wire format
+	}, event, numWant)
+
+	resp := binary.BigEndian.AppendUint32(nil, uint32(s.Interval.Seconds()))
+	resp = binary.BigEndian.AppendUint32(resp, uint32(incomplete)) //nolint: gosec // peer count
+	resp = binary.BigEndian.AppendUint32(resp, uint32(complete))   //nolint: gosec // peer count
+
+	// only peers of the same address family fit in the response
+	for _, peer := range peers {
+		if peer.Addr.Addr().Is4() == clientAddr.Addr().Is4() {
+			resp = AppendCompactAddrPort(resp, peer.Addr)
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *UDPTrackerServer) scrape(payload []byte) ([]byte, error) {
+	hashSize := len(InfoHash{})
+	if len(payload)%hashSize != 0 || len(payload)/hashSize > udpMaxScrape {
+		return nil, TrackerFailureError{"malformed scrape"}
+	}
+
+	var resp []byte
+
+	for start := 0; start < len(payload); start += hashSize {
+		hash := InfoHash(payload[start : start+hashSize])
+
+		var file ScrapeFile
+		if s.Allowed == nil || s.Allowed(hash) {
+			file = s.Swarms.Scrape(hash)[hash]
+		}
+
+		resp = binary.BigEndian.AppendUint32(resp, uint32(file.Complete))   //nolint: gosec // peer count
+		resp = binary.BigEndian.AppendUint32(resp, uint32(file.Downloaded)) //nolint: gosec // peer count
+		resp = binary.BigEndian.AppendUint32(resp, uint32(file.Incomplete)) //nolint: gosec // peer count
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,202 @@
+package ben_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UDP tracker", func() {
+	var (
+		conn     net.PacketConn
+		tracker  *ben.UDPTrackerServer
+		client   *ben.UDPTrackerClient
+		announce string
+		hash     ben.InfoHash
+		seeder   ben.AnnounceRequest
+		leecher  ben.AnnounceRequest
+		served   chan error
+	)
+
+	serve := func() {
+		served = make(chan error, 1)
+		go func() { served <- tracker.Serve(conn) }()
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		served = nil
+		conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		tracker = ben.NewUDPTrackerServer(ben.NewSwarms(time.Minute), 30*time.Second)
+		client = &ben.UDPTrackerClient{Timeout: 100 * time.Millisecond, MaxRetries: 2}
+		announce = "udp://" + conn.LocalAddr().String() + "/announce?passkey=abc"
+
+		hash = ben.InfoHash([]byte("01234567890123456789"))
+		seeder = ben.AnnounceRequest{
+			InfoHash: hash,
+			PeerID:   ben.PeerID([]byte("-BN0001-seeder000000")),
+			Port:     6881,
+			Event:    ben.EventStarted,
+		}
+		leecher = ben.AnnounceRequest{
+			InfoHash: hash,
+			PeerID:   ben.PeerID([]byte("-BN0001-leecher00000")),
+			Port:     6882,
+			Left:     100,
+			Event:    ben.EventStarted,
+		}
+	})
+
+	AfterEach(func() {
+		_ = conn.Close()
+		if served != nil {
+			Eventually(served).Should(Receive(BeNil()))
+		}
+	})
+
+	It("announces and returns peers of the same swarm", func() {
+		serve()
+
+		resp, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Interval).To(Equal(int64(30)))
+		Expect(resp.Peers).To(BeEmpty())
+
+		resp, err = client.Announce(context.Background(), announce, leecher)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Complete).To(Equal(int64(1)))
+		Expect(resp.Incomplete).To(Equal(int64(1)))
+		Expect(resp.Peers).To(HaveLen(1))
+		Expect(resp.Peers[0].String()).To(Equal("127.0.0.1:6881"))
+	})
+
+	It("scrapes swarm statistics", func() {
+		serve()
+
+		_, err := client.Announce(context.Background(), announce, leecher)
+		Expect(err).NotTo(HaveOccurred())
+
+		other := ben.InfoHash([]byte("98765432109876543210"))
+
+		scrape, err := client.Scrape(context.Background(), announce, hash, other)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scrape.Files).To(Equal(ben.ScrapeFiles{
+			hash:  {Incomplete: 1},
+			other: {},
+		}))
+	})
+
+	It("passes the BEP 41 URL data to the server", func() {
+		urlData := make(chan string, 2)
+		tracker.URLData = func(data string) error {
+			urlData <- data
+			if data != "/announce?passkey=abc" {
+				return errors.New("bad passkey")
+			}
+
+			return nil
+		}
+		serve()
+
+		_, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(urlData).To(Receive(Equal("/announce?passkey=abc")))
+
+		_, err = client.Announce(context.Background(), "udp://"+conn.LocalAddr().String()+"/announce?passkey=x", seeder)
+		Expect(err).To(MatchError(ben.TrackerFailureError{Reason: "bad passkey"}))
+	})
+
+	It("reports errors sent by the tracker", func() {
+		tracker.Allowed = func(ben.InfoHash) bool { return false }
+		serve()
+
+		_, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).To(MatchError(ben.TrackerFailureError{Reason: "unregistered torrent"}))
+	})
+
+	It("retransmits lost requests", func() {
+		served = make(chan error, 1)
+		go func() {
+			buff := make([]byte, 2048)
+			_, _, _ = conn.ReadFrom(buff)
+			served <- tracker.Serve(conn)
+		}()
+
+		_, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("gives up when the tracker does not respond", func() {
+		_, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).To(MatchError(ben.ErrUDPTrackerTimeout))
+	})
+
+	It("connects again after the tracker rejected the connection id", func() {
+		// a fake tracker whose first connection id is refused
+		served = make(chan error, 1)
+		go func() {
+			var connections uint64
+
+			buff := make([]byte, 2048)
+			for {
+				_, addr, err := conn.ReadFrom(buff)
+				if err != nil {
+					served <- nil
+					return
+				}
+
+				connID, action, txID := binary.BigEndian.Uint64(buff[:8]), binary.BigEndian.Uint32(buff[8:12]), buff[12:16]
+				resp := binary.BigEndian.AppendUint32(nil, action)
+
+				switch {
+				case action == 0: // connect
+					connections++
+					resp = binary.BigEndian.AppendUint64(append(resp, txID...), connections)
+				case connID == 1:
+					resp = append(binary.BigEndian.AppendUint32(nil, 3), txID...) // error
+					resp = append(resp, "invalid connection id"...)
+				default:
+					resp = append(append(resp, txID...), make([]byte, 12)...)
+				}
+
+				_, _ = conn.WriteTo(resp, addr)
+			}
+		}()
+
+		_, err := client.Announce(context.Background(), announce, seeder)
+		Expect(err).To(MatchError(ben.TrackerFailureError{Reason: "invalid connection id"}))
+
+		_, err = client.Announce(context.Background(), announce, seeder)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("stops waiting as soon as the context is cancelled", func() {
+		client.Timeout = time.Minute
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := client.Announce(ctx, announce, seeder)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+
+	It("refuses non-udp urls", func() {
+		_, err := client.Announce(context.Background(), "http://example.com/announce", seeder)
+		Expect(err).To(MatchError(ben.ErrNotUDPTracker))
+	})
+})
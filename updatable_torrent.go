@@ -0,0 +1,116 @@
+package ben
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+)
+
+// TorrentPointer is the value of a BEP 46 mutable item, it points to the latest
+// version of an updatable torrent.
+type TorrentPointer struct {
+	InfoHash InfoHash `ben:"ih"`
+}
+
+func (TorrentPointer) TryFrom(d Dictionary) (TorrentPointer, error) {
+	return castFromDictionaryInto[TorrentPointer](d)
+}
+
+func (p TorrentPointer) TryInto() (Dictionary, error) {
+	return castFromStructIntoDictionary(p)
+}
+
+// NewTorrentPointerItem signs a pointer to infoHash as the seq-th version of the torrent.
+func NewTorrentPointerItem(key ed25519.PrivateKey, salt string, seq int64, infoHash InfoHash) (MutableItem, error) {
+	value, err := TorrentPointer{InfoHash: infoHash}.TryInto()
+	if err != nil {
+		return MutableItem{}, err
+	}
+
+	return NewMutableItem(key, salt, seq, value), nil
+}
+
+// TorrentPointerFromItem reads the pointer stored in a mutable item.
+func TorrentPointerFromItem(item MutableItem) (TorrentPointer, error) {
+	return castFromDictionaryElement[TorrentPointer](item.Value)
+}
+
+// MutableItemClient is the part of a DHT client needed to follow and publish
+// updatable torrents, it is implemented by DHT.
+type MutableItemClient interface {
+	GetMutable(ctx context.Context, key PublicKey, salt string) (MutableItem, error)
+	PutMutable(ctx context.Context, item MutableItem, cas *int64) error
+}
+
+// TorrentResolver follows updatable torrents (BEP 46) through a DHT client.
+type TorrentResolver struct {
+	Client MutableItemClient
+}
+
+// Resolve returns the info-hash the torrent of key and salt currently points to,
+// along with its sequence number.
+func (r TorrentResolver) Resolve(ctx context.Context, key PublicKey, salt string) (InfoHash, int64, error) {
+	item, err := r.Client.GetMutable(ctx, key, salt)
+	if err != nil {
+		return InfoHash{}, 0, err
+	}
+
+	pointer, err := TorrentPointerFromItem(item)
+	if err != nil {
+		return InfoHash{}, item.Seq, err
+	}
+
+	return pointer.InfoHash, item.Seq, nil
+}
+
+// ResolveMagnet returns a copy of an updatable magnet link pointing to the latest info-hash.
+func (r TorrentResolver) ResolveMagnet(ctx context.Context, m Magnet) (Magnet, error) {
+	if !m.IsUpdatable() {
+		return m, ErrNotUpdatable
+	}
+
+	infoHash, _, err := r.Resolve(ctx, m.PublicKey, m.Salt)
+	if err != nil {
+		return m, err
+	}
+
+	m.InfoHash = infoHash
+
+	return m, nil
+}
+
+// Publish points the torrent of key and salt to infoHash, as the version following
+// the current one, and returns its sequence number. The update fails when another
+// version is published concurrently.
+func (r TorrentResolver) Publish(ctx context.Context, key ed25519.PrivateKey, salt string, infoHash InfoHash) (int64, error) {
+	var (
+		pub PublicKey
+		cas *int64
+		seq = int64(1)
+	)
+
+	copy(pub[:], key.Public().(ed25519.PublicKey)) //nolint: forcetypeassert // always ed25519
+
+	current, err := r.Client.GetMutable(ctx, pub, salt)
+
+	switch {
+	case err == nil:
+		cas = &current.Seq
+		seq = current.Seq + 1
+
+	case !errors.Is(err, ErrItemNotFound):
+		return 0, err
+	}
+
+	item, err := NewTorrentPointerItem(key, salt, seq, infoHash)
+	if err != nil {
+		return seq, err
+	}
+
+	return seq, r.Client.PutMutable(ctx, item, cas)
+}
+
+// UpdatableMagnet returns the magnet link of the torrent of key and salt.
+func UpdatableMagnet(key PublicKey, salt string) Magnet {
+	return Magnet{PublicKey: key, Salt: salt}
+}
@@ -0,0 +1,108 @@
+package ben_test
+
+import (
+	"context"
+	"crypto/ed25519"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+// memoryItemClient is an in-memory DHT, backed by an item store.
+type memoryItemClient struct {
+	store *ben.ItemStore
+}
+
+func (c memoryItemClient) GetMutable(_ context.Context, key ben.PublicKey, salt string) (ben.MutableItem, error) {
+	item, ok := c.store.Mutable(ben.MutableTarget(key, salt))
+	if !ok {
+		return item, ben.ErrItemNotFound
+	}
+
+	return item, nil
+}
+
+func (c memoryItemClient) PutMutable(_ context.Context, item ben.MutableItem, cas *int64) error {
+	return c.store.PutMutable(item, cas)
+}
+
+var _ = Describe("Updatable torrents", func() {
+	var (
+		pub      ben.PublicKey
+		key      ed25519.PrivateKey
+		resolver ben.TorrentResolver
+		first    = ben.InfoHash([]byte("first info-hash 0001"))
+		second   = ben.InfoHash([]byte("second info-hash 002"))
+	)
+
+	BeforeEach(func() {
+		pubKey, privKey, err := ed25519.GenerateKey(nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		pub, key = ben.PublicKey(pubKey), privKey
+		resolver = ben.TorrentResolver{Client: memoryItemClient{store: ben.NewItemStore()}}
+	})
+
+	It("encodes the pointer as a dictionary with the info-hash", func() {
+		item, err := ben.NewTorrentPointerItem(key, "", 1, first)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(item.Value.Encode())).To(Equal("d2:ih20:first info-hash 0001e"))
+		Expect(item.Validate()).To(Succeed())
+
+		pointer, err := ben.TorrentPointerFromItem(item)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pointer.InfoHash).To(Equal(first))
+	})
+
+	It("publishes new versions and resolves the latest one", func() {
+		seq, err := resolver.Publish(context.Background(), key, "nightly", first)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(seq).To(Equal(int64(1)))
+
+		seq, err = resolver.Publish(context.Background(), key, "nightly", second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(seq).To(Equal(int64(2)))
+
+		infoHash, seq, err := resolver.Resolve(context.Background(), pub, "nightly")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(infoHash).To(Equal(second))
+		Expect(seq).To(Equal(int64(2)))
+
+		m, err := resolver.ResolveMagnet(context.Background(), ben.UpdatableMagnet(pub, "nightly"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.InfoHash).To(Equal(second))
+		Expect(m.IsUpdatable()).To(BeTrue())
+	})
+
+	It("fails for unknown or non-updatable torrents", func() {
+		_, _, err := resolver.Resolve(context.Background(), pub, "missing")
+		Expect(err).To(MatchError(ben.ErrItemNotFound))
+
+		_, err = resolver.ResolveMagnet(context.Background(), ben.Magnet{InfoHash: first})
+		Expect(err).To(MatchError(ben.ErrNotUpdatable))
+	})
+
+	It("rejects items that are not pointers", func() {
+		Expect(resolver.Client.PutMutable(context.Background(), ben.NewMutableItem(key, "", 1, ben.Str("x")), nil)).
+			To(Succeed())
+
+		_, _, err := resolver.Resolve(context.Background(), pub, "")
+		Expect(err).To(MatchError(ben.ErrNotADict))
+	})
+
+	It("follows pointers through the DHT", func() {
+		nodes, _ := startDHTNetwork(5)
+
+		_, err := ben.TorrentResolver{Client: nodes[1]}.Publish(context.Background(), key, "", first)
+		Expect(err).NotTo(HaveOccurred())
+
+		m, err := ben.TorrentResolver{Client: nodes[3]}.ResolveMagnet(context.Background(), ben.UpdatableMagnet(pub, ""))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.InfoHash).To(Equal(first))
+	})
+})
@@ -0,0 +1,79 @@
+package ben
+
+const (
+	// ExtensionUTMetadata is the extension name of metadata exchange in the handshake (BEP 9).
+	ExtensionUTMetadata = "ut_metadata"
+
+	// MetadataPieceSize is the size of every metadata piece but the last one.
+	MetadataPieceSize = 16 * 1024
+)
+
+// ut_metadata message types.
+const (
+	MetadataRequest int64 = iota
+	MetadataData
+	MetadataReject
+)
+
+// MetadataMessage is the dictionary of a ut_metadata message (BEP 9),
+// TotalSize is only sent with data messages.
+type MetadataMessage struct {
+	Type      int64 `ben:"msg_type"`
+	Piece     int64 `ben:"piece"`
+	TotalSize int64 `ben:"total_size,omitempty"`
+}
+
+func (MetadataMessage) TryFrom(d Dictionary) (MetadataMessage, error) {
+	return castFromDictionaryInto[MetadataMessage](d)
+}
+
+func (m MetadataMessage) TryInto() (Dictionary, error) {
+	return castFromStructIntoDictionary(m)
+}
+
+// ParseMetadataMessage decodes the payload of a ut_metadata message, returning its dictionary
+// and the metadata piece following it in data messages. Messages of unknown types are returned
+// as is, they are to be ignored.
+func ParseMetadataMessage(payload []byte) (MetadataMessage, []byte, error) {
+	dict, n, err := DecodePrefix[Dictionary](payload)
+	if err != nil {
+		return MetadataMessage{}, nil, err
+	}
+
+	msg, err := MetadataMessage{}.TryFrom(dict)
+	if err != nil {
+		return msg, nil, err
+	}
+
+	if msg.Piece < 0 {
+		return msg, nil, ErrInvalidMetadataMessage
+	}
+
+	data := payload[n:]
+
+	switch msg.Type {
+	case MetadataData:
+		if msg.TotalSize <= 0 || len(data) == 0 || len(data) > MetadataPieceSize {
+			return msg, nil, ErrInvalidMetadataMessage
+		}
+
+		return msg, data, nil
+
+	case MetadataRequest, MetadataReject:
+		if len(data) > 0 {
+			return msg, nil, ErrInvalidMetadataMessage
+		}
+	}
+
+	return msg, nil, nil
+}
+
+// EncodeMetadataMessage encodes msg followed by data, the metadata piece of data messages.
+func EncodeMetadataMessage(msg MetadataMessage, data []byte) ([]byte, error) {
+	dict, err := msg.TryInto()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dict.Encode(), data...), nil
+}
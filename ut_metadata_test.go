@@ -0,0 +1,81 @@
+package ben_test
+
+import (
+	"strings"
+
+	"github.com/fudanchii/ben"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Decoding a prefix", func() {
+	It("returns where the element ended", func() {
+		dict, n, err := ben.DecodePrefix[ben.Dictionary]([]byte("d3:fooi42ee trailing"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(11))
+		Expect(dict.Val).To(HaveKeyWithValue("foo", ben.Int(42)))
+
+		str, n, err := ben.DecodePrefix[ben.String]([]byte("4:spamspam"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(6))
+		Expect(str.Into()).To(Equal("spam"))
+	})
+
+	It("fails on truncated input", func() {
+		_, _, err := ben.DecodePrefix[ben.Dictionary]([]byte("d3:foo"))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("ut_metadata messages", func() {
+	It("decodes the BEP 9 examples", func() {
+		msg, data, err := ben.ParseMetadataMessage([]byte("d8:msg_typei0e5:piecei0ee"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg).To(Equal(ben.MetadataMessage{Type: ben.MetadataRequest}))
+		Expect(data).To(BeEmpty())
+
+		msg, data, err = ben.ParseMetadataMessage([]byte("d8:msg_typei1e5:piecei0e10:total_sizei34256eexxxxxxxx"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg).To(Equal(ben.MetadataMessage{Type: ben.MetadataData, TotalSize: 34256}))
+		Expect(string(data)).To(Equal("xxxxxxxx"))
+
+		msg, _, err = ben.ParseMetadataMessage([]byte("d8:msg_typei2e5:piecei0ee"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Type).To(Equal(ben.MetadataReject))
+	})
+
+	It("keeps trailing bytes that look like bencode", func() {
+		piece := "d4:name4:spam12:piece lengthi16384ee"
+		raw, err := ben.EncodeMetadataMessage(ben.MetadataMessage{Type: ben.MetadataData, Piece: 1, TotalSize: 16422}, []byte(piece))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(raw)).To(Equal("d8:msg_typei1e5:piecei1e10:total_sizei16422ee" + piece))
+
+		msg, data, err := ben.ParseMetadataMessage(raw)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Piece).To(Equal(int64(1)))
+		Expect(string(data)).To(Equal(piece))
+	})
+
+	It("passes unknown message types through", func() {
+		msg, _, err := ben.ParseMetadataMessage([]byte("d8:msg_typei7e5:piecei0ee"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(msg.Type).To(Equal(int64(7)))
+	})
+
+	It("rejects malformed messages", func() {
+		for _, raw := range []string{
+			"d8:msg_typei1e5:piecei0e10:total_sizei10ee",
+			"d8:msg_typei1e5:piecei0eexx",
+			"d8:msg_typei0e5:piecei-1ee",
+			"d8:msg_typei0e5:piecei0eexx",
+			"d8:msg_typei1e5:piecei0e10:total_sizei10ee" + strings.Repeat("x", ben.MetadataPieceSize+1),
+		} {
+			_, _, err := ben.ParseMetadataMessage([]byte(raw))
+			Expect(err).To(MatchError(ben.ErrInvalidMetadataMessage), raw)
+		}
+	})
+})
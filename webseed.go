@@ -0,0 +1,100 @@
+package ben
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// WebSeedRequest is a single HTTP request against a BEP 19 web seed.
+type WebSeedRequest struct {
+	URL string
+	// Range is the value for the HTTP Range header, empty for zero-length files.
+	Range string
+	Span  FileSpan
+}
+
+// WebSeedURL returns the URL for the file covered by span.
+// For single-file torrents, the torrent name is appended only when seed ends with a slash,
+// multi-file torrents always have the name and the file path appended.
+func (i Info) WebSeedURL(seed string, span FileSpan) string {
+	if !i.IsMultiFile() && !strings.HasSuffix(seed, "/") {
+		return seed
+	}
+
+	if !strings.HasSuffix(seed, "/") {
+		seed += "/"
+	}
+
+	escaped := make([]string, 0, len(span.Path))
+	for _, p := range span.Path {
+		escaped = append(escaped, url.PathEscape(p))
+	}
+
+	return seed + strings.Join(escaped, "/")
+}
+
+// WebSeedRequests returns requests needed to fetch length bytes of torrent content
+// starting at offset from seed, one request per file touched.
+// Padding files are skipped, their content is all zeroes.
+func (i Info) WebSeedRequests(seed string, offset, length int64) []WebSeedRequest {
+	spans := i.FileSpans(offset, length)
+	reqs := make([]WebSeedRequest, 0, len(spans))
+
+	for _, span := range spans {
+		if span.Padding {
+			continue
+		}
+
+		reqs = append(reqs, WebSeedRequest{
+			URL:   i.WebSeedURL(seed, span),
+			Range: byteRange(span),
+			Span:  span,
+		})
+	}
+
+	return reqs
+}
+
+// PieceWebSeedRequests returns requests needed to fetch a whole piece from seed.
+func (i Info) PieceWebSeedRequests(seed string, index int) ([]WebSeedRequest, error) {
+	size, err := i.PieceSize(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.WebSeedRequests(seed, int64(index)*i.PieceLength, size), nil
+}
+
+// FileWebSeedRequest returns the request needed to fetch a whole file from seed.
+func (i Info) FileWebSeedRequest(seed string, fileIndex int) (WebSeedRequest, error) {
+	files := i.files()
+
+	if fileIndex < 0 || fileIndex >= len(files) {
+		return WebSeedRequest{}, fmt.Errorf("%w: %d", ErrFileOutOfRange, fileIndex)
+	}
+
+	if files[fileIndex].IsPadding() {
+		return WebSeedRequest{}, fmt.Errorf("%w: %d", ErrPaddingFile, fileIndex)
+	}
+
+	span := FileSpan{
+		FileIndex: fileIndex,
+		Path:      append([]string{i.Name}, files[fileIndex].Path...),
+		Length:    files[fileIndex].Length,
+	}
+
+	return WebSeedRequest{
+		URL:   i.WebSeedURL(seed, span),
+		Range: byteRange(span),
+		Span:  span,
+	}, nil
+}
+
+func byteRange(span FileSpan) string {
+	if span.Length == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("bytes=%d-%d", span.Offset, span.Offset+span.Length-1)
+}
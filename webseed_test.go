@@ -0,0 +1,108 @@
+package ben_test
+
+import (
+	"bufio"
+	"bytes"
+
+	"github.com/fudanchii/ben"
+	"github.com/fudanchii/infr"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/ginkgo/v2"
+
+	//nolint:revive // due to ginkgo convention
+	. "github.com/onsi/gomega"
+)
+
+func decodeTorrent(source string) (ben.Torrent, error) {
+	dict, err := ben.Decode[ben.Dictionary](bufio.NewReader(bytes.NewBufferString(source)))
+	if err != nil {
+		return ben.Torrent{}, err
+	}
+
+	return infr.TryFrom[ben.Dictionary, ben.Torrent](dict).TryInto()
+}
+
+var _ = Describe("Web Seeds", func() {
+	Context("decoding url-list", func() {
+		It("accepts a single string", func() {
+			torrent, err := decodeTorrent("d4:infod4:name3:abc6:pieces0:e8:url-list13:http://a/abc/e")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(torrent.URLList).To(Equal(ben.URLList{"http://a/abc/"}))
+
+			dict, err := torrent.TryInto()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(dict.Encode())).To(Equal("d4:infod4:name3:abc6:pieces0:e8:url-list13:http://a/abc/e"))
+		})
+
+		//nolint: lll
+		It("accepts a list of strings", func() {
+			torrent, err := decodeTorrent("d9:httpseedsl9:http://c/e4:infod4:name3:abc6:pieces0:e8:url-listl9:http://a/9:http://b/ee")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(torrent.URLList).To(Equal(ben.URLList{"http://a/", "http://b/"}))
+			Expect(torrent.HTTPSeeds).To(Equal([]string{"http://c/"}))
+		})
+
+		It("rejects other types", func() {
+			_, err := decodeTorrent("d4:infod4:name3:abc6:pieces0:e8:url-listi1ee")
+			Expect(err).To(MatchError(ben.ErrNotAList))
+		})
+	})
+
+	Context("single-file torrent", func() {
+		info := ben.Info{
+			Name:        "file name.iso",
+			Length:      20,
+			PieceLength: 8,
+			Pieces:      make([]ben.SHA1, 3),
+		}
+
+		It("appends the name only to directory URLs", func() {
+			reqs, err := info.PieceWebSeedRequests("http://a/pub/", 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reqs).To(HaveLen(1))
+			Expect(reqs[0].URL).To(Equal("http://a/pub/file%20name.iso"))
+			Expect(reqs[0].Range).To(Equal("bytes=16-19"))
+
+			reqs, err = info.PieceWebSeedRequests("http://a/pub/file.iso", 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reqs[0].URL).To(Equal("http://a/pub/file.iso"))
+			Expect(reqs[0].Range).To(Equal("bytes=0-7"))
+		})
+
+		It("rejects pieces out of range", func() {
+			_, err := info.PieceWebSeedRequests("http://a/", 3)
+			Expect(err).To(MatchError(ben.ErrPieceOutOfRange))
+		})
+	})
+
+	Context("multi-file torrent", func() {
+		info := ben.Info{
+			Name:        "abc",
+			PieceLength: 8,
+			Pieces:      make([]ben.SHA1, 3),
+			Files: []ben.File{
+				{Length: 6, Path: []string{"a.txt"}},
+				{Length: 6, Path: []string{"sub", "b.txt"}},
+				{Length: 6, Path: []string{"c.txt"}},
+			},
+		}
+
+		It("splits a piece across files", func() {
+			reqs, err := info.PieceWebSeedRequests("http://a/pub", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reqs).To(HaveLen(2))
+			Expect(reqs[0].URL).To(Equal("http://a/pub/abc/sub/b.txt"))
+			Expect(reqs[0].Range).To(Equal("bytes=2-5"))
+			Expect(reqs[1].URL).To(Equal("http://a/pub/abc/c.txt"))
+			Expect(reqs[1].Range).To(Equal("bytes=0-3"))
+		})
+
+		It("returns a request for a whole file", func() {
+			req, err := info.FileWebSeedRequest("http://a/", 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(req.URL).To(Equal("http://a/abc/c.txt"))
+			Expect(req.Range).To(Equal("bytes=0-5"))
+		})
+	})
+})